@@ -0,0 +1,46 @@
+/*******************************************************************************
+ * Copyright (c) 2018  charles
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NON INFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ * -------------------------------------------------------------------------
+ * created at 2026-10-20 11:40:05
+ ******************************************************************************/
+
+// Command gof works on the configuration of the gof packages,
+// programs with their own objects add gofcmd.ConfigCommand to their command line instead.
+package main
+
+import (
+	"os"
+
+	"github.com/atcharles/gof/gofcmd"
+	_ "github.com/atcharles/gof/gofmiddleware"
+	"github.com/spf13/cobra"
+)
+
+func main() {
+	root := &cobra.Command{
+		Use:          "gof",
+		SilenceUsage: true,
+	}
+	root.AddCommand(gofcmd.ConfigCommand())
+	if err := root.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,210 @@
+/*******************************************************************************
+ * Copyright (c) 2018  charles
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NON INFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ * -------------------------------------------------------------------------
+ * created at 2026-10-21 09:33:10
+ ******************************************************************************/
+
+// Package gofapp manages the lifecycle of a program: the components are started in order
+// and stopped in the reverse order on SIGINT or SIGTERM.
+//
+//	app := gofapp.New().Append(
+//		gofapp.ConfigHook(),
+//		gofapp.CacheHook(),
+//		gofapp.Hook{Name: "database", Start: goform.Start, Stop: goform.Stop},
+//		gofapp.HTTPServer(&http.Server{Addr: ":8100", Handler: router}),
+//	)
+//	if err := app.Run(); err != nil {
+//		log.Fatalln(err.Error())
+//	}
+package gofapp
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/atcharles/gof/gofutils/errors"
+)
+
+// DefaultStopTimeout is the deadline of the whole shutdown.
+var DefaultStopTimeout = 30 * time.Second
+
+// Hook is one component of the program. Start, Stop and Err are optional.
+type Hook struct {
+	Name  string
+	Start func() error
+	Stop  func(ctx context.Context) error
+	// Err receives the failure of the component after Start returned, e.g. a server which stops serving,
+	// Run stops the hooks and returns the error
+	Err <-chan error
+}
+
+// App starts the hooks in the order they were appended and stops them in the reverse order.
+type App struct {
+	// StopTimeout is the deadline of the whole shutdown
+	StopTimeout time.Duration
+
+	mu      sync.Mutex
+	hooks   []Hook
+	started []Hook
+	err     error
+	done    chan struct{}
+	once    sync.Once
+}
+
+// New ...
+func New() *App {
+	return &App{
+		StopTimeout: DefaultStopTimeout,
+		done:        make(chan struct{}),
+	}
+}
+
+// Append adds hooks after the hooks already appended.
+func (a *App) Append(hooks ...Hook) *App {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.hooks = append(a.hooks, hooks...)
+	return a
+}
+
+// Start starts the hooks in order. When a hook fails, the hooks already started are stopped
+// and the error is returned. No more hook is started after Shutdown, the hooks started are left to Stop.
+func (a *App) Start() error {
+	a.mu.Lock()
+	hooks := a.hooks[len(a.started):]
+	a.mu.Unlock()
+	for _, h := range hooks {
+		select {
+		case <-a.done:
+			log.Printf("shutting down, %s is not started\n", h.Name)
+			return nil
+		default:
+		}
+		if h.Start != nil {
+			if err := h.Start(); err != nil {
+				err = fmt.Errorf("start %s: %s", h.Name, err.Error())
+				if stopErr := a.Stop(); stopErr != nil {
+					return errors.Append(err, stopErr)
+				}
+				return err
+			}
+		}
+		a.mu.Lock()
+		a.started = append(a.started, h)
+		a.mu.Unlock()
+		log.Printf("%s started\n", h.Name)
+		if h.Err != nil {
+			go a.watch(h)
+		}
+	}
+	return nil
+}
+
+// watch waits for the failure of the hook until Shutdown.
+func (a *App) watch(h Hook) {
+	select {
+	case err := <-h.Err:
+		if err == nil {
+			return
+		}
+		log.Printf("%s failed err:%s, shutting down\n", h.Name, err.Error())
+		a.mu.Lock()
+		if a.err == nil {
+			a.err = fmt.Errorf("%s: %s", h.Name, err.Error())
+		}
+		a.mu.Unlock()
+		a.Shutdown()
+	case <-a.done:
+	}
+}
+
+// Stop stops the started hooks in the reverse order within StopTimeout.
+// Every hook is stopped even when an earlier one fails or the deadline has passed.
+func (a *App) Stop() error {
+	a.mu.Lock()
+	started := a.started
+	a.started = nil
+	a.mu.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), a.StopTimeout)
+	defer cancel()
+	var errs error
+	for i := len(started) - 1; i >= 0; i-- {
+		h := started[i]
+		if h.Stop == nil {
+			continue
+		}
+		if err := h.Stop(ctx); err != nil {
+			errs = errors.Append(errs, fmt.Errorf("stop %s: %s", h.Name, err.Error()))
+			continue
+		}
+		log.Printf("%s stopped\n", h.Name)
+	}
+	return errs
+}
+
+// Shutdown makes Run stop the hooks and return.
+func (a *App) Shutdown() {
+	a.once.Do(func() {
+		close(a.done)
+	})
+}
+
+// Run starts the hooks, waits for SIGINT, SIGTERM, Shutdown or the failure of a hook and stops the hooks.
+// A signal received while the hooks start stops the hooks already started.
+// The failure of a hook is returned with the errors of Stop. A second signal exits the program immediately.
+func (a *App) Run() error {
+	sig := make(chan os.Signal, 2)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sig)
+	go func() {
+		select {
+		case s := <-sig:
+			log.Printf("received %s, shutting down\n", s)
+			a.Shutdown()
+		case <-a.done:
+		}
+		if s, ok := <-sig; ok {
+			log.Printf("received %s again, exit\n", s)
+			os.Exit(1)
+		}
+	}()
+	if err := a.Start(); err != nil {
+		return err
+	}
+	<-a.done
+	err := a.Stop()
+	a.mu.Lock()
+	failed := a.err
+	a.mu.Unlock()
+	if failed == nil {
+		return err
+	}
+	if err != nil {
+		return errors.Append(failed, err)
+	}
+	return failed
+}
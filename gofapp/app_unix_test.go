@@ -0,0 +1,91 @@
+//go:build !windows
+// +build !windows
+
+/*******************************************************************************
+ * Copyright (c) 2018  charles
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NON INFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ * -------------------------------------------------------------------------
+ * created at 2026-11-07 18:40:17
+ ******************************************************************************/
+
+package gofapp
+
+import (
+	"context"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRunSignalDuringStart(t *testing.T) {
+	a := New()
+	var events []string
+	a.Append(
+		Hook{
+			Name: "first",
+			Start: func() error {
+				events = append(events, "start first")
+				return nil
+			},
+			Stop: func(ctx context.Context) error {
+				events = append(events, "stop first")
+				return nil
+			},
+		},
+		Hook{
+			Name: "slow",
+			Start: func() error {
+				events = append(events, "start slow")
+				if err := syscall.Kill(syscall.Getpid(), syscall.SIGINT); err != nil {
+					return err
+				}
+				select {
+				case <-a.done:
+				case <-time.After(5 * time.Second):
+					t.Error("the signal did not shut the app down")
+				}
+				return nil
+			},
+			Stop: func(ctx context.Context) error {
+				events = append(events, "stop slow")
+				return nil
+			},
+		},
+		Hook{
+			Name: "last",
+			Start: func() error {
+				events = append(events, "start last")
+				return nil
+			},
+		},
+	)
+	if err := a.Run(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"start first", "start slow", "stop slow", "stop first"}
+	if len(events) != len(want) {
+		t.Fatalf("events %v, want %v", events, want)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Fatalf("events %v, want %v", events, want)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+/*******************************************************************************
+ * Copyright (c) 2018  charles
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NON INFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ * -------------------------------------------------------------------------
+ * created at 2026-10-21 10:05:44
+ ******************************************************************************/
+
+package gofapp
+
+import (
+	"context"
+	"net"
+	"net/http"
+
+	"github.com/atcharles/gof/gofcache"
+	"github.com/atcharles/gof/gofconf"
+	"github.com/atcharles/gof/gofcron"
+	"github.com/atcharles/gof/goflogger"
+	"github.com/atcharles/gof/gofpool"
+	"github.com/atcharles/gof/gofqueue"
+)
+
+// ConfigHook reads the configuration, see gofconf.Start.
+func ConfigHook() Hook {
+	return Hook{Name: "config", Start: gofconf.Start, Stop: gofconf.Stop}
+}
+
+// LoggerHook flushes and closes the log files when the program stops,
+// append it right after ConfigHook so it is stopped last but one.
+func LoggerHook() Hook {
+	return Hook{Name: "logger", Stop: goflogger.Close}
+}
+
+// ConfigSyncHook propagates the configuration changes to the other instances over redis,
+// append it after CacheHook, see gofcache.StartConfigSync.
+func ConfigSyncHook() Hook {
+	return Hook{Name: "config sync", Start: gofcache.StartConfigSync, Stop: gofcache.StopConfigSync}
+}
+
+// CacheHook initializes the caches of gofcache, see gofcache.Start.
+func CacheHook() Hook {
+	return Hook{Name: "cache", Start: gofcache.Start, Stop: gofcache.Stop}
+}
+
+// PoolHook drains the pool when the program stops, the jobs already queued are run before the deadline.
+// gofconf.Job is drained by ConfigHook.
+func PoolHook(p *gofpool.Pool) Hook {
+	return Hook{Name: "pool " + p.Name(), Stop: p.Shutdown}
+}
+
+// QueueHook polls the queue, append it after CacheHook when the queue uses redis.
+func QueueHook(q *gofqueue.Queue) Hook {
+	return Hook{Name: "queue", Start: q.Start, Stop: q.Stop}
+}
+
+// CronHook starts the scheduler, append it after CacheHook when the singleton jobs lock in redis.
+func CronHook(s *gofcron.Scheduler) Hook {
+	return Hook{Name: "cron", Start: s.Start, Stop: s.Stop}
+}
+
+// HTTPServer listens when the hook starts, so an address in use is returned by Start,
+// and shuts the server down gracefully, in-flight requests are completed before the deadline.
+// A failure of the server after Start stops the app, see Hook.Err.
+func HTTPServer(srv *http.Server) Hook {
+	errc := make(chan error, 1)
+	return Hook{
+		Name: "http server " + srv.Addr,
+		Err:  errc,
+		Start: func() error {
+			addr := srv.Addr
+			if addr == "" {
+				addr = ":http"
+			}
+			ln, err := net.Listen("tcp", addr)
+			if err != nil {
+				return err
+			}
+			go func() {
+				if err := srv.Serve(ln); err != nil && err != http.ErrServerClosed {
+					select {
+					case errc <- err:
+					default:
+					}
+				}
+			}()
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			return srv.Shutdown(ctx)
+		},
+	}
+}
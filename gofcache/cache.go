@@ -25,6 +25,7 @@
 package gofcache
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -34,8 +35,8 @@ import (
 	"time"
 
 	"github.com/atcharles/gof/gofconf"
+	"github.com/atcharles/gof/gofutils/errors"
 	"github.com/go-redis/redis"
-	"github.com/spf13/viper"
 	"github.com/tidwall/buntdb"
 )
 
@@ -68,6 +69,7 @@ func init() {
 	MeCache = NewMemoryCache()
 }
 
+//InitCache ...
 func InitCache() {
 	switch gofconf.DefaultProcess.CacheType {
 	case "redis":
@@ -78,6 +80,27 @@ func InitCache() {
 	MeCache = NewMemoryCache()
 }
 
+//Start initializes the caches like InitCache and checks the redis connection
+func Start() error {
+	InitCache()
+	if r, ok := DefCache.(*RedisCache); ok {
+		return r.Client.Ping().Err()
+	}
+	return nil
+}
+
+//Stop closes the redis connections and the memory database
+func Stop(ctx context.Context) error {
+	var errs error
+	if RedisGlobalCache != nil {
+		errs = errors.Append(errs, RedisGlobalCache.Client.Close())
+	}
+	if MeCache != nil {
+		errs = errors.Append(errs, MeCache.Client.Close())
+	}
+	return errs
+}
+
 //NewRedisCache ...
 func NewRedisCache() *RedisCache {
 	if RedisGlobalCache != nil {
@@ -86,10 +109,11 @@ func NewRedisCache() *RedisCache {
 	cache := &RedisCache{
 		mu: new(sync.Mutex),
 	}
-	op := &redis.Options{}
-	err := viper.UnmarshalKey("redis", op)
-	if err != nil {
-		log.Fatalf("load redis err : %s\n", err.Error())
+	// DefaultRedis holds the decrypted password
+	op := &redis.Options{
+		Addr:     gofconf.DefaultRedis.Addr,
+		Password: gofconf.DefaultRedis.Password,
+		DB:       gofconf.DefaultRedis.DB,
 	}
 	client := redis.NewClient(op)
 	cache.Client = client
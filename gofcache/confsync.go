@@ -0,0 +1,210 @@
+/*******************************************************************************
+ * Copyright (c) 2018  charles
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NON INFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ * -------------------------------------------------------------------------
+ * created at 2026-10-30 11:02:47
+ ******************************************************************************/
+
+package gofcache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/atcharles/gof/gofconf"
+	"github.com/go-redis/redis"
+)
+
+// The configuration sync publishes the configurations applied by an instance to a redis channel,
+// and the other instances apply them with gofconf.Apply, which validates them before the reload.
+// Every message carries a version incremented in redis, an instance ignores its own messages
+// and the messages older than the last version it has seen.
+const (
+	//ConfigChannel is the channel of the configurations
+	ConfigChannel = "gofconf:changes"
+	//ConfigVersionKey is the counter of the configuration versions
+	ConfigVersionKey = "gofconf:version"
+)
+
+var (
+	//ConfigPublishSources are the sources of the versions published to the other instances,
+	//the startup and the per-instance sources (secret files, remote polling) are not published
+	ConfigPublishSources = map[string]bool{
+		gofconf.SourceWatch:    true,
+		gofconf.SourceRollback: true,
+		gofconf.SourceAPI:      true,
+	}
+
+	confSyncMu   sync.Mutex
+	confSyncOnce sync.Once
+	confSync     *configSync
+)
+
+type configMessage struct {
+	Origin   string `json:"origin"`
+	Version  int64  `json:"version"`
+	Source   string `json:"source"`
+	Ext      string `json:"ext"`
+	Checksum string `json:"checksum"`
+	Content  []byte `json:"content"`
+}
+
+type configSync struct {
+	client *redis.Client
+	origin string
+	pubsub *redis.PubSub
+	mu     sync.Mutex
+	last   int64
+	done   chan struct{}
+}
+
+// StartConfigSync subscribes to ConfigChannel with the redis client of NewRedisCache
+func StartConfigSync() error {
+	confSyncMu.Lock()
+	defer confSyncMu.Unlock()
+	if confSync != nil {
+		return nil
+	}
+	client := NewRedisCache().Client
+	pubsub := client.Subscribe(ConfigChannel)
+	if _, err := pubsub.Receive(); err != nil {
+		pubsub.Close()
+		return err
+	}
+	s := &configSync{
+		client: client,
+		origin: instanceID(),
+		pubsub: pubsub,
+		done:   make(chan struct{}),
+	}
+	confSync = s
+	confSyncOnce.Do(func() {
+		gofconf.OnVersion(publishConfig)
+	})
+	go s.receive()
+	return nil
+}
+
+// StopConfigSync unsubscribes, the redis client is closed by Stop
+func StopConfigSync(ctx context.Context) error {
+	confSyncMu.Lock()
+	s := confSync
+	confSync = nil
+	confSyncMu.Unlock()
+	if s == nil {
+		return nil
+	}
+	err := s.pubsub.Close()
+	select {
+	case <-s.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return err
+}
+
+// publishConfig is called by gofconf for every applied version
+func publishConfig(v gofconf.ConfigVersion) {
+	confSyncMu.Lock()
+	s := confSync
+	confSyncMu.Unlock()
+	if s == nil || !ConfigPublishSources[v.Source] {
+		return
+	}
+	version, err := s.client.Incr(ConfigVersionKey).Result()
+	if err != nil {
+		log.Printf("config sync: publish err:%s\n", err.Error())
+		return
+	}
+	s.seen(version)
+	b, err := json.Marshal(configMessage{
+		Origin:   s.origin,
+		Version:  version,
+		Source:   v.Source,
+		Ext:      filepath.Ext(v.File),
+		Checksum: v.Checksum,
+		Content:  v.Content,
+	})
+	if err == nil {
+		err = s.client.Publish(ConfigChannel, string(b)).Err()
+	}
+	if err != nil {
+		log.Printf("config sync: publish err:%s\n", err.Error())
+	}
+}
+
+func (s *configSync) receive() {
+	defer close(s.done)
+	for msg := range s.pubsub.Channel() {
+		var m configMessage
+		if err := json.Unmarshal([]byte(msg.Payload), &m); err != nil {
+			log.Printf("config sync: bad message err:%s\n", err.Error())
+			continue
+		}
+		if !s.accept(m) {
+			continue
+		}
+		if err := gofconf.Apply(m.Content, gofconf.SourcePeer); err != nil {
+			log.Printf("config sync: apply version %d from %s err:%s\n", m.Version, m.Origin, err.Error())
+		}
+	}
+}
+
+// accept reports whether a message of another instance is applied, the own messages,
+// the versions older than the last one and the files of another format are ignored
+func (s *configSync) accept(m configMessage) bool {
+	if m.Origin == s.origin {
+		return false
+	}
+	if !s.seen(m.Version) {
+		log.Printf("config sync: version %d from %s is older than the applied one, ignored\n", m.Version, m.Origin)
+		return false
+	}
+	if ext := filepath.Ext(gofconf.ConfigFile()); ext != m.Ext {
+		log.Printf("config sync: version %d is a %s file, the global file is %s, ignored\n", m.Version, m.Ext, ext)
+		return false
+	}
+	return true
+}
+
+// seen records the version, it reports false when the version is not newer than the last one
+func (s *configSync) seen(version int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if version <= s.last {
+		return false
+	}
+	s.last = version
+	return true
+}
+
+func instanceID() string {
+	host, _ := os.Hostname()
+	b := make([]byte, 4)
+	rand.Read(b)
+	return host + ":" + strconv.Itoa(os.Getpid()) + ":" + hex.EncodeToString(b)
+}
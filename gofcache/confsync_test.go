@@ -0,0 +1,104 @@
+/*******************************************************************************
+ * Copyright (c) 2018  charles
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NON INFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ * -------------------------------------------------------------------------
+ * created at 2026-11-08 15:21:40
+ ******************************************************************************/
+
+package gofcache
+
+import (
+	"errors"
+	"net"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/atcharles/gof/gofconf"
+	"github.com/go-redis/redis"
+)
+
+// testSync installs a configuration sync whose redis client counts the dials and fails them
+func testSync(t *testing.T) (*configSync, *int32) {
+	var dials int32
+	client := redis.NewClient(&redis.Options{
+		Dialer: func() (net.Conn, error) {
+			atomic.AddInt32(&dials, 1)
+			return nil, errors.New("no redis in tests")
+		},
+	})
+	s := &configSync{client: client, origin: "test:1", done: make(chan struct{})}
+	confSyncMu.Lock()
+	confSync = s
+	confSyncMu.Unlock()
+	t.Cleanup(func() {
+		confSyncMu.Lock()
+		confSync = nil
+		confSyncMu.Unlock()
+		client.Close()
+	})
+	return s, &dials
+}
+
+func TestConfigSyncPublishSources(t *testing.T) {
+	tests := []struct {
+		source  string
+		publish bool
+	}{
+		{gofconf.SourcePeer, false},
+		{gofconf.SourceStartup, false},
+		{gofconf.SourceRemote, false},
+		{gofconf.SourceSecretFile, false},
+		{gofconf.SourceWatch, true},
+		{gofconf.SourceRollback, true},
+		{gofconf.SourceAPI, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			_, dials := testSync(t)
+			publishConfig(gofconf.ConfigVersion{Source: tt.source, File: "app.yaml", Content: []byte("a: 1")})
+			if got := atomic.LoadInt32(dials) > 0; got != tt.publish {
+				t.Fatalf("published %v, want %v", got, tt.publish)
+			}
+		})
+	}
+}
+
+func TestConfigSyncAccept(t *testing.T) {
+	s, _ := testSync(t)
+	ext := filepath.Ext(gofconf.ConfigFile())
+	steps := []struct {
+		name string
+		m    configMessage
+		want bool
+	}{
+		{"own", configMessage{Origin: s.origin, Version: 5, Ext: ext}, false},
+		{"newer", configMessage{Origin: "peer", Version: 3, Ext: ext}, true},
+		{"duplicate", configMessage{Origin: "peer", Version: 3, Ext: ext}, false},
+		{"older", configMessage{Origin: "other", Version: 2, Ext: ext}, false},
+		{"other format", configMessage{Origin: "peer", Version: 4, Ext: ext + "x"}, false},
+		{"after other format", configMessage{Origin: "peer", Version: 6, Ext: ext}, true},
+	}
+	for _, st := range steps {
+		if got := s.accept(st.m); got != st.want {
+			t.Fatalf("%s: accept %v, want %v", st.name, got, st.want)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+/*******************************************************************************
+ * Copyright (c) 2018  charles
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NON INFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ * -------------------------------------------------------------------------
+ * created at 2026-10-23 09:12:44
+ ******************************************************************************/
+
+package gofcache
+
+import (
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/tidwall/buntdb"
+)
+
+// Locker is a lock shared by the instances using the same cache
+type Locker interface {
+	//Lock sets key to token when key is not set, the lock expires after ttl
+	Lock(key, token string, ttl time.Duration) (bool, error)
+	//Unlock deletes key when it is still held by token
+	Unlock(key, token string) error
+}
+
+// DefLocker returns DefCache as a Locker
+func DefLocker() Locker {
+	if l, ok := DefCache.(Locker); ok {
+		return l
+	}
+	return NewMemoryCache()
+}
+
+var unlockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// Lock ...
+func (r *RedisCache) Lock(key, token string, ttl time.Duration) (bool, error) {
+	return r.Client.SetNX(key, token, ttl).Result()
+}
+
+// Unlock ...
+func (r *RedisCache) Unlock(key, token string) error {
+	return unlockScript.Run(r.Client, []string{key}, token).Err()
+}
+
+// Lock ...
+func (m *MemoryCache) Lock(key, token string, ttl time.Duration) (bool, error) {
+	locked := false
+	err := m.Client.Update(func(tx *buntdb.Tx) error {
+		_, err := tx.Get(key)
+		if err == nil {
+			return nil
+		}
+		if err != buntdb.ErrNotFound {
+			return err
+		}
+		_, _, err = tx.Set(key, token, &buntdb.SetOptions{Expires: ttl > 0, TTL: ttl})
+		locked = err == nil
+		return err
+	})
+	return locked, err
+}
+
+// Unlock ...
+func (m *MemoryCache) Unlock(key, token string) error {
+	return m.Client.Update(func(tx *buntdb.Tx) error {
+		val, err := tx.Get(key)
+		if err == buntdb.ErrNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if val != token {
+			return nil
+		}
+		_, err = tx.Delete(key)
+		return err
+	})
+}
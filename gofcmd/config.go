@@ -0,0 +1,270 @@
+/*******************************************************************************
+ * Copyright (c) 2018  charles
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NON INFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ * -------------------------------------------------------------------------
+ * created at 2026-10-20 11:02:38
+ ******************************************************************************/
+
+// Package gofcmd provides the command line of the gof packages.
+package gofcmd
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/atcharles/gof/gofconf"
+	"github.com/spf13/cobra"
+)
+
+// ConfigCommand returns the `config` command, it works on the objects registered with
+// gofconf.AddDefaultInformation, so it is meant to be added to the root command of the program:
+//
+//	root.AddCommand(gofcmd.ConfigCommand())
+func ConfigCommand() *cobra.Command {
+	var confDir, confFile string
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Generate, validate, print and edit the configuration",
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			if confDir != "" {
+				gofconf.SetConfigDir(confDir)
+			}
+			if confFile != "" {
+				gofconf.SetConfigFile(confFile)
+			}
+		},
+	}
+	cmd.PersistentFlags().StringVar(&confDir, "conf-dir", "", "directory of the configuration files, env "+gofconf.ConfDirEnv)
+	cmd.PersistentFlags().StringVar(&confFile, "conf-file", "", "name of the global configuration file, env "+gofconf.ConfFileEnv)
+	cmd.AddCommand(
+		templateCommand(),
+		validateCommand(),
+		printCommand(),
+		setCommand(),
+		encryptCommand(),
+		historyCommand(),
+		diffCommand(),
+		rollbackCommand(),
+	)
+	return cmd
+}
+
+func defaultFormat() string {
+	return strings.TrimPrefix(gofconf.ConfigExt(), ".")
+}
+
+func templateCommand() *cobra.Command {
+	var format, output string
+	var force bool
+	cmd := &cobra.Command{
+		Use:   "template",
+		Short: "Generate a commented configuration file with the default values",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if format == "" {
+				format = defaultFormat()
+			}
+			b, err := gofconf.Template(format)
+			if err != nil {
+				return err
+			}
+			if output == "" {
+				_, err = os.Stdout.Write(b)
+				return err
+			}
+			if _, err := os.Stat(output); err == nil && !force {
+				return fmt.Errorf("%s already exists, use --force to overwrite it", output)
+			}
+			return ioutil.WriteFile(output, b, 0644)
+		},
+	}
+	cmd.Flags().StringVarP(&format, "format", "f", "", "yaml, json or toml, defaults to the format of the global file")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "write to the file instead of stdout")
+	cmd.Flags().BoolVar(&force, "force", false, "overwrite the output file")
+	return cmd
+}
+
+func validateCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "validate [file]",
+		Short: "Validate a configuration file without starting the program",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			fileName := gofconf.ConfigFile()
+			if len(args) > 0 {
+				fileName = args[0]
+			}
+			if err := gofconf.Validate(fileName); err != nil {
+				return err
+			}
+			fmt.Printf("%s is valid\n", fileName)
+			return nil
+		},
+	}
+}
+
+func printCommand() *cobra.Command {
+	var format string
+	cmd := &cobra.Command{
+		Use:   "print",
+		Short: "Print the effective configuration, including the environment overrides, secrets are redacted",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if format == "" {
+				format = defaultFormat()
+			}
+			b, err := gofconf.Effective(gofconf.ConfigFile(), format)
+			if err != nil {
+				return err
+			}
+			_, err = os.Stdout.Write(b)
+			return err
+		},
+	}
+	cmd.Flags().StringVarP(&format, "format", "f", "", "yaml, json or toml, defaults to the format of the global file")
+	return cmd
+}
+
+func setCommand() *cobra.Command {
+	var encrypt bool
+	cmd := &cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "Set one key of the global file, e.g. `set process.listenport 8080`, comments are not kept",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			fileName := gofconf.ConfigFile()
+			if err := gofconf.SetValue(fileName, args[0], args[1], encrypt); err != nil {
+				return err
+			}
+			return gofconf.Validate(fileName)
+		},
+	}
+	cmd.Flags().BoolVar(&encrypt, "encrypt", false, "write the value as ENC(...)")
+	return cmd
+}
+
+func encryptCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "encrypt [value]",
+		Short: "Encrypt a value for the configuration file, the value is read from stdin when it is omitted",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var value string
+			if len(args) > 0 {
+				value = args[0]
+			} else {
+				line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+				if err != nil && line == "" {
+					return err
+				}
+				value = strings.TrimRight(line, "\r\n")
+			}
+			enc, err := gofconf.EncryptValue(value)
+			if err != nil {
+				return err
+			}
+			fmt.Println(enc)
+			return nil
+		},
+	}
+}
+
+func historyCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "history",
+		Short: "List the applied versions of the configuration with their changes, secrets are redacted",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			for _, v := range gofconf.History() {
+				fmt.Printf("%d\t%s\t%s\t%s\n", v.ID, v.Time.Format("2006-01-02 15:04:05"), v.Source, v.Checksum[:12])
+				printChanges(v.Changes, "\t")
+			}
+			return nil
+		},
+	}
+}
+
+func diffCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "diff <version> <version>",
+		Short: "Print the changes between two versions, secrets are redacted",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ids, err := versionArgs(args)
+			if err != nil {
+				return err
+			}
+			changes, err := gofconf.Diff(ids[0], ids[1])
+			if err != nil {
+				return err
+			}
+			printChanges(changes, "")
+			return nil
+		},
+	}
+}
+
+func rollbackCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "rollback <version>",
+		Short: "Write a version back to the global file, the running program reloads it",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ids, err := versionArgs(args)
+			if err != nil {
+				return err
+			}
+			// the command exists to write the file, the read-only mode would only load it in memory
+			if err := gofconf.SetConfigMode(gofconf.ModeBootstrap); err != nil {
+				return err
+			}
+			return gofconf.Rollback(ids[0])
+		},
+	}
+}
+
+func versionArgs(args []string) ([]int, error) {
+	ids := make([]int, len(args))
+	for i, arg := range args {
+		id, err := strconv.Atoi(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version: %s", arg)
+		}
+		ids[i] = id
+	}
+	return ids, nil
+}
+
+func printChanges(changes []gofconf.Change, indent string) {
+	for _, c := range changes {
+		switch {
+		case c.Old == "" && c.New != "":
+			fmt.Printf("%s+ %s = %s\n", indent, c.Key, c.New)
+		case c.New == "" && c.Old != "":
+			fmt.Printf("%s- %s = %s\n", indent, c.Key, c.Old)
+		default:
+			fmt.Printf("%s~ %s: %s -> %s\n", indent, c.Key, c.Old, c.New)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+/*******************************************************************************
+ * Copyright (c) 2018  charles
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NON INFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ * -------------------------------------------------------------------------
+ * created at 2026-10-30 10:18:33
+ ******************************************************************************/
+
+package gofconf
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// Apply validates the content and applies it like a change of the global file, the source is recorded
+// in the history. The content must be in the format of the global file, and a remote configuration
+// cannot be changed. The file is only written in the bootstrap mode, in the read-only mode the content
+// is loaded in memory and the file is left as it is, see ConfigMode.
+func Apply(content []byte, source string) error {
+	if IsRemote() {
+		return errors.New("config: a remote configuration cannot be changed")
+	}
+	fileName := ConfigFile()
+	if !IsBootstrap() {
+		return applyInMemory(fileName, content, source)
+	}
+	if old, err := ioutil.ReadFile(fileName); err == nil && bytes.Equal(old, content) {
+		return nil
+	}
+	ext := filepath.Ext(fileName)
+	tmp := strings.TrimSuffix(fileName, ext) + "." + source + ext
+	if err := ioutil.WriteFile(tmp, content, 0644); err != nil {
+		return err
+	}
+	if err := Validate(tmp); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, fileName); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if viper.ConfigFileUsed() != "" {
+		reloadFrom(source)
+	}
+	return nil
+}
+
+// applyInMemory validates the content and reads it into viper without touching the file.
+func applyInMemory(fileName string, content []byte, source string) error {
+	if v := latestVersion(); v != nil && bytes.Equal(v.Content, content) {
+		return nil
+	}
+	vp, err := readConfigContent(fileName, content)
+	if err != nil {
+		return err
+	}
+	if err := validateConfig(vp); err != nil {
+		return err
+	}
+	return reloadWith(source, fileName, content, func() error {
+		return viper.ReadConfig(bytes.NewReader(content))
+	})
+}
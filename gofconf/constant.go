@@ -75,10 +75,12 @@ const (
 const (
 	HeaderAccept              = "Accept"
 	HeaderAcceptEncoding      = "Accept-Encoding"
+	HeaderAcceptLanguage      = "Accept-Language"
 	HeaderAllow               = "Allow"
 	HeaderAuthorization       = "Authorization"
 	HeaderContentDisposition  = "Content-Disposition"
 	HeaderContentEncoding     = "Content-Encoding"
+	HeaderContentLanguage     = "Content-Language"
 	HeaderContentLength       = "Content-Length"
 	HeaderContentType         = "Content-Type"
 	HeaderCookie              = "Cookie"
@@ -130,6 +132,7 @@ var (
 	ErrUnauthorized                = NewHTTPError(http.StatusUnauthorized)
 	ErrForbidden                   = NewHTTPError(http.StatusForbidden)
 	ErrMethodNotAllowed            = NewHTTPError(http.StatusMethodNotAllowed)
+	ErrNotAcceptable               = NewHTTPError(http.StatusNotAcceptable)
 	ErrStatusRequestEntityTooLarge = NewHTTPError(http.StatusRequestEntityTooLarge)
 	ErrValidatorNotRegistered      = errors.New("validator not registered")
 	ErrRendererNotRegistered       = errors.New("renderer not registered")
@@ -142,7 +145,13 @@ type (
 	HTTPError struct {
 		Code     int         `json:"code"`
 		Message  interface{} `json:"message"`
+		Details  interface{} `json:"details,omitempty"`
 		Internal error       `json:"-"` //Errors returned by external dependencies can be stored
+		// BizCode is the stable application code rendered instead of Code, see gofi18n.Register
+		BizCode int `json:"-"`
+		// MessageKey is translated with Args by the error handler for the language of the request
+		MessageKey string        `json:"-"`
+		Args       []interface{} `json:"-"`
 	}
 )
 
@@ -159,3 +168,17 @@ func NewHTTPError(code int, message ...interface{}) *HTTPError {
 	}
 	return he
 }
+
+// WithInternal returns a copy of the error holding err, the predefined errors are not modified.
+func (he *HTTPError) WithInternal(err error) *HTTPError {
+	c := *he
+	c.Internal = err
+	return &c
+}
+
+// WithDetails returns a copy of the error with details, the predefined errors are not modified.
+func (he *HTTPError) WithDetails(details interface{}) *HTTPError {
+	c := *he
+	c.Details = details
+	return &c
+}
@@ -0,0 +1,196 @@
+/*******************************************************************************
+ * Copyright (c) 2018  charles
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NON INFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ * -------------------------------------------------------------------------
+ * created at 2026-10-19 13:48:52
+ ******************************************************************************/
+
+package gofconf
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/atcharles/gof/gofutils"
+)
+
+// EnvPrefix is the prefix of the environment variables overriding configuration fields.
+// The variable of a field is the prefix, the configuration key and the field name
+// in upper snake case, e.g. GOF_PROCESS_LISTEN_PORT or GOF_REDIS_ADDR.
+// The `mapstructure` tag of a field replaces its name.
+// Slices are comma separated, durations use the time.ParseDuration format or nanoseconds.
+var EnvPrefix = "GOF"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// EnvName joins the prefix and the parts into an environment variable name.
+func EnvName(parts ...string) string {
+	name := EnvPrefix
+	for _, p := range parts {
+		name += "_" + strings.ToUpper(p)
+	}
+	return name
+}
+
+// ResolveObj applies the environment overrides to the struct ptr points to,
+// reads its `file://` values and decrypts its encrypted values.
+// prefix is the variable name of the struct, see EnvName.
+func ResolveObj(prefix string, ptr interface{}) error {
+	if err := ApplyEnv(prefix, ptr); err != nil {
+		return err
+	}
+	if err := ResolveFiles(ptr); err != nil {
+		return err
+	}
+	return DecryptSecrets(ptr)
+}
+
+// ApplyEnv overrides the fields of the struct ptr points to with the environment variables
+// named prefix_FIELD, nested structs use prefix_FIELD_SUBFIELD.
+// prefix_FIELD_FILE names a file holding the value, see ResolveFiles.
+func ApplyEnv(prefix string, ptr interface{}) error {
+	v := reflect.ValueOf(ptr)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("apply env: %T is not a struct pointer", ptr)
+	}
+	return applyEnvStruct(prefix, v.Elem())
+}
+
+func applyEnvStruct(prefix string, v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < v.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := envFieldName(field)
+		if name == "" {
+			continue
+		}
+		name = prefix + "_" + name
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Ptr && fv.Type().Elem().Kind() == reflect.Struct {
+			if fv.IsNil() {
+				continue
+			}
+			fv = fv.Elem()
+		}
+		if fv.Kind() == reflect.Struct {
+			if err := applyEnvStruct(name, fv); err != nil {
+				return err
+			}
+			continue
+		}
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			fileName, ok := os.LookupEnv(name + fileEnvSuffix)
+			if !ok {
+				continue
+			}
+			var err error
+			if value, err = readSecretFile(fileName); err != nil {
+				return fmt.Errorf("env %s: %s", name+fileEnvSuffix, err.Error())
+			}
+		}
+		if err := setEnvValue(fv, value); err != nil {
+			return fmt.Errorf("env %s: %s", name, err.Error())
+		}
+	}
+	return nil
+}
+
+func envFieldName(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("mapstructure"), ",")[0]
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		name = gofutils.SnakeString(field.Name)
+	}
+	return strings.ToUpper(name)
+}
+
+func setEnvValue(v reflect.Value, value string) error {
+	if v.Type() == durationType {
+		d, err := parseDuration(value)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i, err := strconv.ParseUint(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(i)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		items := make([]string, 0)
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		slice := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := setEnvValue(slice.Index(i), item); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+	default:
+		return fmt.Errorf("unsupported field type %s", v.Type())
+	}
+	return nil
+}
+
+// parseDuration accepts `30s`, `1m30s`... or a number of nanoseconds.
+func parseDuration(value string) (time.Duration, error) {
+	if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Duration(i), nil
+	}
+	return time.ParseDuration(value)
+}
@@ -25,22 +25,33 @@
 package gofconf
 
 import (
+	"context"
 	"log"
 	"os"
-	"runtime"
+	"reflect"
+	"sync"
 	"time"
 
+	"github.com/atcharles/gof/gofpool"
 	"github.com/atcharles/gof/gofutils"
+	"github.com/atcharles/gof/gofutils/errors"
 	"github.com/fsnotify/fsnotify"
-	"github.com/ivpusic/grpool"
 	"github.com/spf13/viper"
 )
 
 var (
 	//Queue External configuration, you must pass the queue in the use method.
-	Queue          = make(chan func())
-	Job            = grpool.NewPool(100, runtime.NumCPU())
+	Queue = make(chan func())
+	//Job The shared worker pool, it is drained by Stop.
+	Job            = gofpool.New("gofconf", 100, 1024)
 	innerFuncGroup = make([]Init, 0)
+	defaultObjs    = make(map[Init]interface{})
+	ownObjs        int // the objects of gofconf, at the end of innerFuncGroup
+	loadMu         sync.Mutex
+	loadHooks      []func()
+
+	queueMu   sync.Mutex
+	queueQuit chan struct{}
 )
 
 // Init ...
@@ -50,112 +61,248 @@ type (
 	}
 )
 
-//AddDefaultInformation ...
+//AddDefaultInformation registers objects read by Initialize and on every reload,
+//they are read in order before the objects of gofconf.
 func AddDefaultInformation(obj ...Init) {
-	innerFuncGroup = append(innerFuncGroup, obj...)
+	n := len(innerFuncGroup) - ownObjs
+	group := make([]Init, 0, len(innerFuncGroup)+len(obj))
+	group = append(append(append(group, innerFuncGroup[:n]...), obj...), innerFuncGroup[n:]...)
+	innerFuncGroup = group
+	for _, o := range obj {
+		defaultObjs[o] = deepCopy(reflect.ValueOf(o)).Interface()
+	}
+}
+
+// OnLoad registers fn, it is called after every InitFunc ran: by Start and on every reload,
+// whether the values changed or not, see Subscribe.
+func OnLoad(fn func()) {
+	loadMu.Lock()
+	defer loadMu.Unlock()
+	loadHooks = append(loadHooks, fn)
+}
+
+func runLoadHooks() {
+	loadMu.Lock()
+	hooks := loadHooks
+	loadMu.Unlock()
+	for _, fn := range hooks {
+		fn()
+	}
+}
+
+// defaultOf returns a copy of obj as it was registered, with its defaults.
+func defaultOf(obj Init) interface{} {
+	return deepCopy(reflect.ValueOf(defaultObjs[obj])).Interface()
 }
 
 // ReadObjInformation Read information from the configuration file into a global variable,
 // and if there is no information about the object in the configuration file,
 // write the initial properties of the object to the configuration file.
+// The file is only written in the bootstrap mode, see ConfigMode.
+// The environment variables are applied afterwards, see EnvPrefix.
 func ReadObjInformation(ptr Init) error {
-	key := gofutils.SnakeString(gofutils.ObjectName(ptr))
+	key := objKey(ptr)
 	if viper.IsSet(key) {
-		return viper.UnmarshalKey(key, ptr)
+		if err := viper.UnmarshalKey(key, ptr); err != nil {
+			return err
+		}
+	} else if !IsBootstrap() {
+		WarnDefaults(activeConfigFile(), key)
+	} else if err := writeObjInformation(key, ptr); err != nil {
+		return err
+	}
+	return ResolveObj(EnvName(key), ptr)
+}
+
+// writeObjInformation writes the initial properties of the object to the configuration file.
+// yaml and toml sections are appended with the comments of the fields, the rest of the file is kept as it is.
+func writeObjInformation(key string, ptr Init) error {
+	value, err := EncryptSecrets(ptr)
+	if err != nil {
+		return err
+	}
+	fileName := viper.ConfigFileUsed()
+	configType, err := ConfigType(fileName)
+	if err != nil {
+		return err
+	}
+	if configType == "json" {
+		// Write through a separate instance, a value set on the global viper would
+		// shadow every later change of the file.
+		vp, err := readConfigFile(fileName)
+		if err != nil {
+			return err
+		}
+		vp.Set(key, value)
+		if err := vp.WriteConfig(); err != nil {
+			return err
+		}
+		return readWrittenConfig()
+	}
+	b, err := renderNodes(configType, []*confNode{sectionNode(key, reflect.ValueOf(value))})
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(fileName, os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if stat, err := f.Stat(); err == nil && stat.Size() > 0 {
+		b = append([]byte("\n"), b...)
 	}
-	viper.Set(key, ptr)
 	// Execute the initialization event,Write to the configuration file.
-	if err := viper.WriteConfig(); err != nil {
+	if _, err := f.Write(b); err != nil {
 		return err
 	}
-	return nil
+	return readWrittenConfig()
+}
+
+// readWrittenConfig reads the configuration file after a write-back,
+// the version is recorded once every InitFunc has run.
+func readWrittenConfig() error {
+	return viper.ReadInConfig()
 }
 
 func initConfig() error {
-	fileName := gofutils.SelfDir() + "conf/" + GlobalFileName
-	if err := gofutils.TouchFile(fileName); err != nil {
-		panic(err.Error())
+	fileName := ConfigFile()
+	if r := RemoteConfig(); r != nil {
+		if err := startRemote(r); err != nil {
+			return err
+		}
+		fileName = r.cacheFile()
+	}
+	configType, err := ConfigType(fileName)
+	if err != nil {
+		return err
 	}
-	viper.SetConfigType("yaml")
+	viper.SetConfigType(configType)
 	viper.SetConfigFile(fileName)
 	viper.AutomaticEnv()
+	if IsBootstrap() {
+		if err := TouchConfigFile(fileName); err != nil {
+			return err
+		}
+	} else if !gofutils.FileExists(fileName) {
+		log.Printf("config: %s does not exist, the defaults are used\n", fileName)
+		return nil
+	}
 	if err := viper.ReadInConfig(); err != nil {
 		return err
 	}
 	viper.WatchConfig()
 	viper.OnConfigChange(func(e fsnotify.Event) {
-		viper.ReadInConfig()
-		for _, c := range innerFuncGroup {
-			if err := c.InitFunc(); err != nil {
-				log.Println(err.Error())
-			}
-		}
+		scheduleReload(SourceWatch)
 	})
 	return nil
 }
 
 // Initialize ...
 // This method needs to be referenced when the configuration file needs to be initialized
+// It panics on error, see Start.
 func Initialize() {
-	if err := initConfig(); err != nil {
+	if err := Start(); err != nil {
 		panic(err.Error())
 	}
+}
+
+// Start reads the configuration file and runs every InitFunc, the first error is returned.
+func Start() error {
+	if err := initConfig(); err != nil {
+		return err
+	}
 
-	innerFuncGroup = append(innerFuncGroup,
-		&DefaultProcess,
-		&DefaultRedis,
-		&DefaultLog,
-	)
 	for _, c := range innerFuncGroup {
 		if err := c.InitFunc(); err != nil {
-			panic(err.Error())
+			return err
 		}
 	}
+	runLoadHooks()
+	recordVersion(SourceStartup)
+	snapshotSubscribed()
 
-	Job.JobQueue <- func() {
-		defer func() {
-			if p := recover(); p != nil {
-				log.SetFlags(log.LstdFlags)
-				log.Println(string(gofutils.PanicTrace(4)))
-			}
-		}()
-		//Add timeout validation and exit the current goroutine automatically after more than 10 seconds.
-		to := time.NewTimer(10 * time.Second)
-		for {
-			to.Reset(10 * time.Second)
-			select {
-			case obFunc := <-Queue:
-				obFunc()
-			case <-to.C:
-				return
-			}
-		}
-	}
+	startQueue()
 
 	log.SetFlags(log.LstdFlags)
 	log.Printf("The PID of the current process is: %d \n", os.Getpid())
+	return nil
 }
 
-//AddJobWithTimeout Add a co-procedure with a timeout constraint
-//Add timeout validation and exit the current goroutine automatically after more than 10 seconds.
-func AddJobWithTimeout(out time.Duration, fn func()) {
-	Job.JobQueue <- func() {
-		defer func() {
-			if p := recover(); p != nil {
-				log.SetFlags(log.LstdFlags)
-				log.Println(string(gofutils.PanicTrace(4)))
-			}
-		}()
-		//Add timeout validation and exit the current goroutine automatically after more than 10 seconds.
-		to := time.NewTimer(out)
+// Stop stops reloading the configuration, the values already applied are kept.
+func Stop(ctx context.Context) error {
+	reloadMu.Lock()
+	stopped = true
+	if reloadTimer != nil {
+		reloadTimer.Stop()
+	}
+	reloadMu.Unlock()
+	stopQueue()
+	stopRemote()
+
+	var err error
+	if e := Job.Shutdown(ctx); e != nil {
+		err = errors.Append(err, e)
+	}
+	secretMu.Lock()
+	defer secretMu.Unlock()
+	if secretWatcher != nil {
+		if e := secretWatcher.Close(); e != nil {
+			err = errors.Append(err, e)
+		}
+	}
+	return err
+}
+
+// startQueue consumes Queue in order until Stop, a panic of a function does not stop the consumer.
+func startQueue() {
+	queueMu.Lock()
+	defer queueMu.Unlock()
+	if queueQuit != nil {
+		return
+	}
+	quit := make(chan struct{})
+	queueQuit = quit
+	go func() {
 		for {
-			to.Reset(out)
 			select {
-			case <-to.C:
+			case obFunc := <-Queue:
+				runQueued(obFunc)
+			case <-quit:
 				return
-			default:
-				fn()
 			}
 		}
+	}()
+}
+
+func stopQueue() {
+	queueMu.Lock()
+	defer queueMu.Unlock()
+	if queueQuit != nil {
+		close(queueQuit)
+		queueQuit = nil
+	}
+}
+
+func runQueued(fn func()) {
+	defer func() {
+		if p := recover(); p != nil {
+			log.SetFlags(log.LstdFlags)
+			log.Println(string(gofutils.PanicTrace(4)))
+		}
+	}()
+	fn()
+}
+
+//AddJobWithTimeout Run fn once in Job, fn is dropped when it cannot start within out.
+//The error is returned when the job cannot be queued within out.
+func AddJobWithTimeout(out time.Duration, fn func()) error {
+	ctx, cancel := context.WithTimeout(context.Background(), out)
+	err := Job.Submit(ctx, func(ctx context.Context) {
+		defer cancel()
+		fn()
+	})
+	if err != nil {
+		cancel()
 	}
+	return err
 }
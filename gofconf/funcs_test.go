@@ -0,0 +1,52 @@
+/*******************************************************************************
+ * Copyright (c) 2018  charles
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NON INFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ * -------------------------------------------------------------------------
+ * created at 2026-11-07 18:02:55
+ ******************************************************************************/
+
+package gofconf
+
+import "testing"
+
+type orderConf struct{ Name string }
+
+func (o *orderConf) InitFunc() error { return nil }
+
+func TestAddDefaultInformationOrder(t *testing.T) {
+	saved := innerFuncGroup
+	defer func() {
+		innerFuncGroup = saved
+	}()
+	a, b := &orderConf{Name: "a"}, &orderConf{Name: "b"}
+	AddDefaultInformation(a)
+	AddDefaultInformation(b)
+	defer func() {
+		delete(defaultObjs, a)
+		delete(defaultObjs, b)
+	}()
+	want := []Init{a, b, &DefaultProcess, &DefaultRedis, &DefaultLog}
+	got := innerFuncGroup[len(innerFuncGroup)-len(want):]
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("object %d is %T, want the application objects before the objects of gofconf", i, got[i])
+		}
+	}
+}
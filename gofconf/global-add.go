@@ -35,7 +35,8 @@ import (
 // When writing a file, the tag changes according to the file type.
 // For example, json, yaml...
 const (
-	// GlobalFileName is the name of the global program configuration file
+	// GlobalFileName is the default name of the global program configuration file,
+	// see ConfigFile.
 	GlobalFileName = "__global.yaml"
 )
 
@@ -54,39 +55,72 @@ var (
 		ConsoleEnable: true,
 		FileEnable:    true,
 		FilePath:      "logs/web" + gofutils.Delimiter,
-		//Level:         "info",
+		Level:         "info",
+		Format:        "text",
+		RotateSize:    100,
+		RotateEvery:   "daily",
+		MaxDays:       7,
+		Compress:      true,
+		AsyncBuffer:   8192,
+		FlushInterval: time.Second,
+		FullPolicy:    "block",
+		DropLevel:     "info",
 	}
 )
 
 type (
 	// Process Program global configuration items
+	// The `comment` tags are written into the generated configuration files, see Template.
 	Process struct {
 		// Key        string `mapstructure:"-"`                //the name of config key
-		ListenPort   int    // server listen port
-		Mode         string // program run mode,debug or release
-		CacheType    string // redis or memory
-		Secret       string // program secret , use to jwt
-		ReadTimeOut  time.Duration
-		WriteTimeOut time.Duration
+		ListenPort   int           `comment:"server listen port"`
+		Mode         string        `comment:"program run mode, debug or release"`
+		CacheType    string        `comment:"redis or memory"`
+		Secret       string        `secret:"true" comment:"program secret, use to jwt"`
+		ReadTimeOut  time.Duration `comment:"maximum duration for reading the entire request, e.g. 30s, 0 means no timeout"`
+		WriteTimeOut time.Duration `comment:"maximum duration before timing out writes of the response, 0 means no timeout"`
 	}
 	// Redis set;need cacheType = `redis`
 	Redis struct {
-		Addr     string // redis server address;example:127.0.0.1:6379
-		Password string
-		DB       int
+		Addr     string `comment:"redis server address, example: 127.0.0.1:6379"`
+		Password string `secret:"true" comment:"redis password, empty when the server has none"`
+		DB       int    `comment:"redis database number"`
 	}
 	// Log Log system Settings
 	// The server log system is placed in the "logs/web" directory.
-	// Whenever the log file size exceeds 1MB, the system will automatically backup the log file.
-	// The system will only back up the log file for the last 3 days.
+	// The log files are rotated into dated directories by size, by time or both,
+	// see goflogger.Policy.
 	Log struct {
-		ConsoleEnable bool
-		FileEnable    bool
-		FilePath      string // Program current directory;`logs/web`
-		//Level         string // error|warn|info|debug
+		ConsoleEnable bool              `comment:"write the logs of the loggers opted in with goflogger File.SetConsole to the console"`
+		FileEnable    bool              `comment:"write the logs to files"`
+		FilePath      string            `comment:"log directory, relative to the program directory"` // Program current directory;`logs/web`
+		Level         string            `comment:"error, warn, info or debug"`
+		Levels        map[string]string `comment:"levels of the module loggers, e.g. sql: debug, see goflogger.Module"`
+		Format        string            `comment:"text or json"`
+		Service       string            `comment:"service name of the json logs, defaults to the program name"`
+		RotateSize    int               `comment:"rotate a log file when it exceeds the size in MB, 0 disables the size rotation"`
+		RotateEvery   string            `comment:"hourly, daily or empty, rotate the log files at the start of every period"`
+		MaxBackups    int               `comment:"rotated files kept per log file, 0 keeps every file"`
+		MaxDays       int               `comment:"days the rotated files are kept, 0 keeps them forever"`
+		MaxTotal      int               `comment:"disk budget of the rotated files of a log file in MB, 0 is unlimited"`
+		Compress      bool              `comment:"gzip the rotated files in the background"`
+		// RotateExternal lets logrotate manage the files, its postrotate script sends SIGHUP
+		RotateExternal bool `comment:"the files are rotated by an external tool like logrotate and reopened on SIGHUP"`
+		// The async writer is created by the first write after Async is enabled,
+		// the buffer settings are read at that time
+		Async         bool          `comment:"write the logs in the background through a bounded buffer"`
+		AsyncBuffer   int           `comment:"entries buffered per log file by the async writer"`
+		FlushInterval time.Duration `comment:"the async writer flushes its batches at least this often, e.g. 1s"`
+		FullPolicy    string        `comment:"block, drop_newest or drop_level when the buffer is full"`
+		DropLevel     string        `comment:"with drop_level, the entries of this level or less severe are dropped, the others wait"`
 	}
 )
 
+func init() {
+	AddDefaultInformation(&DefaultProcess, &DefaultRedis, &DefaultLog)
+	ownObjs = len(innerFuncGroup)
+}
+
 //InitFunc ReadIn ...
 func (p *Process) InitFunc() error {
 	return ReadObjInformation(&DefaultProcess)
@@ -0,0 +1,446 @@
+/*******************************************************************************
+ * Copyright (c) 2018  charles
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NON INFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ * -------------------------------------------------------------------------
+ * created at 2026-10-29 13:52:18
+ ******************************************************************************/
+
+package gofconf
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Every configuration applied by gofconf is kept in memory as a version: the content of the file,
+// the time, the source of the change and the redacted changes from the previous version.
+// The versions are also written to the history directory when one is set, see SetHistoryDir,
+// so they survive a restart and can be read by the `gof config history` command.
+// The secrets of the written versions are redacted like in Effective.
+const (
+	// ConfHistoryEnv is the environment variable of the history directory
+	ConfHistoryEnv = "GOF_CONF_HISTORY"
+
+	SourceStartup    = "startup"
+	SourceWatch      = "watch"
+	SourceRemote     = "remote"
+	SourceSecretFile = "secret-file"
+	SourceRollback   = "rollback"
+	// SourceAPI is the source of Apply called by the application, e.g. an admin API
+	SourceAPI = "api"
+	// SourcePeer is the source of a configuration received from another instance
+	SourcePeer = "peer"
+)
+
+var (
+	// HistoryLimit is the number of versions kept
+	HistoryLimit = 50
+
+	// ErrVersionNotFound ...
+	ErrVersionNotFound = errors.New("config version not found")
+
+	historyDirValue string
+	historyMu       sync.Mutex
+	historyLoaded   bool
+	versions        []*ConfigVersion
+	versionHooks    []func(v ConfigVersion)
+)
+
+type (
+	// ConfigVersion is a configuration applied at Time.
+	ConfigVersion struct {
+		ID       int       `json:"id"`
+		Time     time.Time `json:"time"`
+		Source   string    `json:"source"`
+		File     string    `json:"file"`
+		Checksum string    `json:"checksum"`
+		// Changes from the previous version, the secrets are redacted
+		Changes []Change `json:"changes,omitempty"`
+		// Content is the file as it was applied, it is not returned by History
+		Content []byte `json:"content,omitempty"`
+		// Redacted is set when the secrets of Content are redacted, for a version read from the history directory
+		Redacted bool `json:"redacted,omitempty"`
+	}
+
+	// Change of a key, Old is empty for an added key and New for a removed one.
+	Change struct {
+		Key string `json:"key"`
+		Old string `json:"old,omitempty"`
+		New string `json:"new,omitempty"`
+	}
+)
+
+// SetHistoryDir sets the directory of the versions, it must be called before Initialize.
+func SetHistoryDir(dir string) {
+	historyDirValue = dir
+}
+
+// HistoryDir returns the directory set by SetHistoryDir or ConfHistoryEnv, empty when the versions are only kept in memory.
+func HistoryDir() string {
+	return firstNotEmpty(historyDirValue, os.Getenv(ConfHistoryEnv))
+}
+
+// History returns the versions without their content, the latest last.
+func History() []ConfigVersion {
+	historyMu.Lock()
+	defer historyMu.Unlock()
+	loadHistory()
+	list := make([]ConfigVersion, len(versions))
+	for i, v := range versions {
+		list[i] = *v
+		list[i].Content = nil
+	}
+	return list
+}
+
+// Diff returns the redacted changes from version a to version b.
+func Diff(a, b int) ([]Change, error) {
+	historyMu.Lock()
+	defer historyMu.Unlock()
+	loadHistory()
+	va, vb := findVersion(a), findVersion(b)
+	if va == nil || vb == nil {
+		return nil, ErrVersionNotFound
+	}
+	return diffVersions(va, vb)
+}
+
+// Rollback applies the content of a version, see Apply: it is written to the configuration file
+// in the bootstrap mode and only loaded in memory in the read-only mode.
+// A version read from the history directory has its secrets redacted, they are taken from the current file.
+func Rollback(id int) error {
+	historyMu.Lock()
+	loadHistory()
+	v := findVersion(id)
+	historyMu.Unlock()
+	if v == nil {
+		return ErrVersionNotFound
+	}
+	content := v.Content
+	if v.Redacted {
+		var err error
+		if content, err = restoreSecrets(v); err != nil {
+			return err
+		}
+	}
+	return Apply(content, SourceRollback)
+}
+
+// OnVersion registers fn, it is called after a new version is applied.
+func OnVersion(fn func(v ConfigVersion)) {
+	historyMu.Lock()
+	defer historyMu.Unlock()
+	versionHooks = append(versionHooks, fn)
+}
+
+// recordVersion keeps the configuration file as a new version when its content changed.
+func recordVersion(source string) {
+	fileName := viper.ConfigFileUsed()
+	if fileName == "" {
+		return
+	}
+	content, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return
+	}
+	recordContent(source, fileName, content)
+}
+
+// recordContent keeps the content of fileName as a new version when it changed.
+func recordContent(source, fileName string, content []byte) {
+	var err error
+	sum := sha256.Sum256(content)
+	v := &ConfigVersion{
+		ID:       1,
+		Time:     time.Now(),
+		Source:   source,
+		File:     fileName,
+		Checksum: hex.EncodeToString(sum[:]),
+		Content:  content,
+	}
+	historyMu.Lock()
+	loadHistory()
+	if n := len(versions); n > 0 {
+		prev := versions[n-1]
+		if prev.Checksum == v.Checksum {
+			historyMu.Unlock()
+			return
+		}
+		v.ID = prev.ID + 1
+		if v.Changes, err = diffVersions(prev, v); err != nil {
+			log.Printf("config: diff of version %d: %s\n", v.ID, err.Error())
+		}
+	}
+	versions = append(versions, v)
+	saveVersion(v)
+	if n := len(versions) - HistoryLimit; HistoryLimit > 0 && n > 0 {
+		for _, old := range versions[:n] {
+			if HistoryDir() != "" {
+				os.Remove(versionFile(old.ID))
+			}
+		}
+		versions = append([]*ConfigVersion(nil), versions[n:]...)
+	}
+	hooks := versionHooks
+	historyMu.Unlock()
+	for _, fn := range hooks {
+		fn(*v)
+	}
+}
+
+// latestVersion returns the version applied last, nil without history.
+func latestVersion() *ConfigVersion {
+	historyMu.Lock()
+	defer historyMu.Unlock()
+	loadHistory()
+	if n := len(versions); n > 0 {
+		return versions[n-1]
+	}
+	return nil
+}
+
+func versionFile(id int) string {
+	return filepath.Join(HistoryDir(), fmt.Sprintf("%06d.json", id))
+}
+
+// saveVersion writes the version with its secrets redacted when the history directory is set,
+// a failure only costs the history after a restart.
+func saveVersion(v *ConfigVersion) {
+	if HistoryDir() == "" {
+		return
+	}
+	var b []byte
+	saved := *v
+	saved.Redacted = true
+	content, err := redactContent(v.File, v.Content)
+	if err == nil {
+		saved.Content = content
+		b, err = json.Marshal(&saved)
+	}
+	if err == nil {
+		err = os.MkdirAll(HistoryDir(), 0700)
+	}
+	if err == nil {
+		err = ioutil.WriteFile(versionFile(v.ID), b, 0600)
+	}
+	if err != nil {
+		log.Printf("config: save version %d: %s\n", v.ID, err.Error())
+	}
+}
+
+// loadHistory reads the versions of the history directory once.
+func loadHistory() {
+	if historyLoaded {
+		return
+	}
+	historyLoaded = true
+	if HistoryDir() == "" {
+		return
+	}
+	files, err := filepath.Glob(filepath.Join(HistoryDir(), "*.json"))
+	if err != nil {
+		return
+	}
+	sort.Strings(files)
+	for _, name := range files {
+		b, err := ioutil.ReadFile(name)
+		if err != nil {
+			continue
+		}
+		v := new(ConfigVersion)
+		if err := json.Unmarshal(b, v); err != nil {
+			log.Printf("config: read version %s: %s\n", name, err.Error())
+			continue
+		}
+		versions = append(versions, v)
+	}
+}
+
+func findVersion(id int) *ConfigVersion {
+	for _, v := range versions {
+		if v.ID == id {
+			return v
+		}
+	}
+	return nil
+}
+
+func diffVersions(a, b *ConfigVersion) ([]Change, error) {
+	rawA, shownA, err := versionValues(a)
+	if err != nil {
+		return nil, err
+	}
+	rawB, shownB, err := versionValues(b)
+	if err != nil {
+		return nil, err
+	}
+	keys := make(map[string]bool)
+	for k := range rawA {
+		keys[k] = true
+	}
+	for k := range rawB {
+		keys[k] = true
+	}
+	changes := make([]Change, 0)
+	for k := range keys {
+		oldValue, okA := rawA[k]
+		newValue, okB := rawB[k]
+		if okA && okB && oldValue == newValue {
+			continue
+		}
+		// a redacted secret cannot be compared
+		if (a.Redacted || b.Redacted) && shownA[k] == shownB[k] {
+			continue
+		}
+		changes = append(changes, Change{Key: k, Old: shownA[k], New: shownB[k]})
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+	return changes, nil
+}
+
+// versionValues returns the values of the file of the version by key path, the keys missing from the file
+// have their registered defaults. Neither the environment nor the `file://` values are applied,
+// raw holds the secrets as they are written in the file and shown the redacted values.
+func versionValues(v *ConfigVersion) (raw, shown map[string]string, err error) {
+	rawNodes, shownNodes, err := contentNodes(v.File, v.Content)
+	if err != nil {
+		return nil, nil, err
+	}
+	raw, shown = make(map[string]string), make(map[string]string)
+	flattenNodes(raw, "", rawNodes)
+	flattenNodes(shown, "", shownNodes)
+	return raw, shown, nil
+}
+
+// contentNodes returns the sections of the registered objects read from content like versionValues,
+// shown has the secrets redacted.
+func contentNodes(fileName string, content []byte) (raw, shown []*confNode, err error) {
+	vp, err := readConfigContent(fileName, content)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, obj := range innerFuncGroup {
+		key := objKey(obj)
+		value := defaultOf(obj)
+		if vp.IsSet(key) {
+			if err := decodeValue(vp.Get(key), value, false); err != nil {
+				return nil, nil, fmt.Errorf("%s: %s", key, err.Error())
+			}
+		}
+		redacted := deepCopy(reflect.ValueOf(value)).Interface()
+		if err := redactSecrets(redacted); err != nil {
+			return nil, nil, err
+		}
+		raw = append(raw, sectionNode(key, reflect.ValueOf(value)))
+		shown = append(shown, sectionNode(key, reflect.ValueOf(redacted)))
+	}
+	return raw, shown, nil
+}
+
+// redactContent renders content with its secrets redacted, in the format of fileName.
+func redactContent(fileName string, content []byte) ([]byte, error) {
+	configType, err := ConfigType(fileName)
+	if err != nil {
+		return nil, err
+	}
+	_, shown, err := contentNodes(fileName, content)
+	if err != nil {
+		return nil, err
+	}
+	return renderNodes(configType, shown)
+}
+
+// restoreSecrets renders the content of a redacted version with the secrets of the current file.
+func restoreSecrets(v *ConfigVersion) ([]byte, error) {
+	configType, err := ConfigType(v.File)
+	if err != nil {
+		return nil, err
+	}
+	current, err := ioutil.ReadFile(v.File)
+	if err != nil {
+		return nil, err
+	}
+	currentRaw, _, err := contentNodes(v.File, current)
+	if err != nil {
+		return nil, err
+	}
+	nodes, _, err := contentNodes(v.File, v.Content)
+	if err != nil {
+		return nil, err
+	}
+	raw, leaves := make(map[string]*confNode), make(map[string]*confNode)
+	leafNodes(raw, "", currentRaw)
+	leafNodes(leaves, "", nodes)
+	for key, n := range leaves {
+		if !strings.Contains(fmt.Sprint(n.value), Redacted) {
+			continue
+		}
+		if raw[key] == nil || fmt.Sprint(raw[key].value) == "" {
+			return nil, fmt.Errorf("%s: the secret is redacted in version %d and missing from %s", key, v.ID, v.File)
+		}
+		n.value = raw[key].value
+	}
+	return renderNodes(configType, nodes)
+}
+
+// leafNodes maps the nodes holding a value by key path.
+func leafNodes(out map[string]*confNode, prefix string, nodes []*confNode) {
+	for _, n := range nodes {
+		key := n.key
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		if n.children != nil {
+			leafNodes(out, key, n.children)
+			continue
+		}
+		out[key] = n
+	}
+}
+
+func flattenNodes(out map[string]string, prefix string, nodes []*confNode) {
+	for _, n := range nodes {
+		key := n.key
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		if n.children != nil {
+			flattenNodes(out, key, n.children)
+			continue
+		}
+		out[key] = fmt.Sprint(n.value)
+	}
+}
@@ -0,0 +1,108 @@
+/*******************************************************************************
+ * Copyright (c) 2018  charles
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NON INFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ * -------------------------------------------------------------------------
+ * created at 2026-11-07 15:20:08
+ ******************************************************************************/
+
+package gofconf
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const historyBody = "process:\n  listenport: 8200\n  secret: s3cret\nredis:\n  password: p4ss\n"
+
+// resetHistory empties the versions and sets the history directory until the end of the test
+func resetHistory(t *testing.T, dir string) {
+	reset := func(dir string) {
+		historyMu.Lock()
+		versions, historyLoaded = nil, false
+		historyMu.Unlock()
+		SetHistoryDir(dir)
+	}
+	reset(dir)
+	t.Cleanup(func() {
+		reset("")
+	})
+}
+
+func writeConf(t *testing.T, fileName, content string) {
+	if err := ioutil.WriteFile(fileName, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHistoryInMemory(t *testing.T) {
+	dir := t.TempDir()
+	SetConfigDir(dir)
+	defer SetConfigDir("")
+	resetHistory(t, "")
+	recordContent(SourceAPI, filepath.Join(dir, "conf.yaml"), []byte(historyBody))
+	if n := len(History()); n != 1 {
+		t.Fatalf("got %d versions, want 1", n)
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 0 {
+		t.Fatalf("the history was written to %s: %s", dir, files[0].Name())
+	}
+}
+
+func TestHistoryRedacted(t *testing.T) {
+	dir, historyDir := t.TempDir(), t.TempDir()
+	fileName := filepath.Join(dir, "conf.yaml")
+	writeConf(t, fileName, historyBody)
+	resetHistory(t, historyDir)
+	recordContent(SourceAPI, fileName, []byte(historyBody))
+
+	b, err := ioutil.ReadFile(versionFile(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(b), "s3cret") || strings.Contains(string(b), "p4ss") {
+		t.Fatalf("the secret was written to the history: %s", b)
+	}
+
+	// read the version back from the directory like after a restart
+	resetHistory(t, historyDir)
+	v := latestVersion()
+	if v == nil || !v.Redacted {
+		t.Fatalf("got version %+v, want a redacted one", v)
+	}
+	content, err := restoreSecrets(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "s3cret") || !strings.Contains(string(content), "p4ss") ||
+		!strings.Contains(string(content), "8200") {
+		t.Fatalf("the secret was not restored: %s", content)
+	}
+
+	writeConf(t, fileName, strings.Replace(historyBody, "  password: p4ss\n", "", 1))
+	if _, err := restoreSecrets(v); err == nil || !strings.Contains(err.Error(), "redis.password") {
+		t.Fatalf("err %v, want the missing secret", err)
+	}
+}
@@ -0,0 +1,78 @@
+/*******************************************************************************
+ * Copyright (c) 2018  charles
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NON INFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ * -------------------------------------------------------------------------
+ * created at 2026-10-20 14:17:52
+ ******************************************************************************/
+
+package gofconf
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// The configuration mode decides whether the configuration files are written.
+// In the read-only mode, the default, the files are never created or rewritten:
+// a missing file or section falls back to the defaults in memory and a warning is logged.
+// The bootstrap mode creates the missing files and writes the missing sections with their defaults.
+// The mode is taken from SetConfigMode, the `-gof.conf.mode` flag (see RegisterFlags) or GOF_CONF_MODE.
+const (
+	// ModeReadOnly never writes the configuration files
+	ModeReadOnly = "readonly"
+	// ModeBootstrap writes the missing files and sections
+	ModeBootstrap = "bootstrap"
+	// ConfModeEnv is the environment variable of the configuration mode
+	ConfModeEnv = "GOF_CONF_MODE"
+)
+
+var confModeValue string
+
+// SetConfigMode sets the configuration mode, ModeReadOnly or ModeBootstrap.
+func SetConfigMode(mode string) error {
+	if mode != ModeReadOnly && mode != ModeBootstrap {
+		return fmt.Errorf("unknown config mode: %s", mode)
+	}
+	confModeValue = mode
+	return nil
+}
+
+// ConfigMode returns the configuration mode, an unknown mode is read-only.
+func ConfigMode() string {
+	mode := strings.ToLower(firstNotEmpty(confModeValue, os.Getenv(ConfModeEnv)))
+	if mode == ModeBootstrap {
+		return ModeBootstrap
+	}
+	return ModeReadOnly
+}
+
+// IsBootstrap reports whether the missing configuration files and sections are written,
+// a remote configuration is never written.
+func IsBootstrap() bool {
+	return ConfigMode() == ModeBootstrap && !IsRemote()
+}
+
+// WarnDefaults logs that a section is missing and the defaults are used in memory.
+func WarnDefaults(fileName, key string) {
+	log.Printf("config: %s has no `%s` section, the defaults are used; run in %s mode to write them\n",
+		fileName, key, ModeBootstrap)
+}
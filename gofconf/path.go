@@ -0,0 +1,165 @@
+/*******************************************************************************
+ * Copyright (c) 2018  charles
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NON INFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ * -------------------------------------------------------------------------
+ * created at 2026-10-19 11:26:05
+ ******************************************************************************/
+
+package gofconf
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/atcharles/gof/gofutils"
+)
+
+// The configuration directory and the global file name are taken from,
+// in order of precedence:
+// SetConfigDir/SetConfigFile or the `-gof.conf.dir`/`-gof.conf.file` flags, see RegisterFlags,
+// the GOF_CONF_DIR/GOF_CONF_FILE environment variables,
+// and finally the `conf` directory next to the executable.
+// The file format follows the extension of the global file: yaml, yml, json or toml.
+const (
+	// ConfDirEnv is the environment variable of the configuration directory
+	ConfDirEnv = "GOF_CONF_DIR"
+	// ConfFileEnv is the environment variable of the global configuration file name
+	ConfFileEnv = "GOF_CONF_FILE"
+)
+
+var (
+	confDirValue  string
+	confFileValue string
+
+	supportedTypes = map[string]string{
+		".yaml": "yaml",
+		".yml":  "yaml",
+		".json": "json",
+		".toml": "toml",
+	}
+)
+
+// RegisterFlags registers the `-gof.conf.dir`, `-gof.conf.file`, `-gof.conf.mode` and `-gof.conf.remote` flags
+// on fs, e.g. flag.CommandLine before flag.Parse. A flag set on the command line acts like its setter,
+// the values set before RegisterFlags are kept as the defaults.
+func RegisterFlags(fs *flag.FlagSet) {
+	fs.StringVar(&confDirValue, "gof.conf.dir", confDirValue, "directory of the configuration files, env "+ConfDirEnv)
+	fs.StringVar(&confFileValue, "gof.conf.file", confFileValue, "name of the global configuration file, env "+ConfFileEnv)
+	fs.StringVar(&confModeValue, "gof.conf.mode", confModeValue, "readonly or bootstrap, env "+ConfModeEnv)
+	fs.StringVar(&confRemoteValue, "gof.conf.remote", confRemoteValue, "URL of the remote configuration, env "+ConfRemoteEnv)
+}
+
+// SetConfigDir sets the configuration directory, it must be called before Initialize.
+func SetConfigDir(dir string) {
+	confDirValue = dir
+}
+
+// SetConfigFile sets the name of the global configuration file, it must be called before Initialize.
+// A path is used as it is and replaces the configuration directory for this file only.
+func SetConfigFile(name string) {
+	confFileValue = name
+}
+
+// ConfigDir returns the configuration directory, always ending with a separator.
+func ConfigDir() string {
+	dir := firstNotEmpty(confDirValue, os.Getenv(ConfDirEnv))
+	if dir == "" {
+		dir = defaultConfigDir()
+	}
+	if !strings.HasSuffix(dir, "/") && !strings.HasSuffix(dir, gofutils.Delimiter) {
+		dir += gofutils.Delimiter
+	}
+	return dir
+}
+
+// defaultConfigDir is the `conf` directory next to the executable.
+// `go run` builds the executable in a temporary directory, the working directory is used instead.
+func defaultConfigDir() string {
+	self := gofutils.SelfDir()
+	tmp, _ := filepath.Abs(os.TempDir())
+	if tmp != "" && strings.HasPrefix(self, tmp+string(filepath.Separator)) {
+		if wd, err := os.Getwd(); err == nil {
+			return filepath.Join(wd, "conf") + gofutils.Delimiter
+		}
+	}
+	return self + "conf" + gofutils.Delimiter
+}
+
+// ConfigFile returns the full name of the global configuration file.
+func ConfigFile() string {
+	name := firstNotEmpty(confFileValue, os.Getenv(ConfFileEnv), GlobalFileName)
+	if filepath.IsAbs(name) || strings.ContainsAny(name, `/\`) {
+		return name
+	}
+	return ConfigDir() + name
+}
+
+// ConfigPath returns the full name of a file in the configuration directory.
+func ConfigPath(name string) string {
+	return ConfigDir() + name
+}
+
+// ConfigExt returns the extension of the global configuration file, other configuration
+// files use the same format.
+func ConfigExt() string {
+	return strings.ToLower(filepath.Ext(ConfigFile()))
+}
+
+// ConfigType returns the viper config type of a file name, e.g. yaml, json or toml.
+func ConfigType(fileName string) (string, error) {
+	t, ok := supportedTypes[strings.ToLower(filepath.Ext(fileName))]
+	if !ok {
+		return "", fmt.Errorf("unsupported config file type: %s", fileName)
+	}
+	return t, nil
+}
+
+// TouchConfigFile creates the configuration file if it does not exist.
+// An empty json file is not valid, so `{}` is written into it.
+func TouchConfigFile(fileName string) error {
+	t, err := ConfigType(fileName)
+	if err != nil {
+		return err
+	}
+	if err := gofutils.TouchFile(fileName); err != nil {
+		return err
+	}
+	if t != "json" {
+		return nil
+	}
+	stat, err := os.Stat(fileName)
+	if err != nil || stat.Size() > 0 {
+		return err
+	}
+	return ioutil.WriteFile(fileName, []byte("{}"), 0644)
+}
+
+func firstNotEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
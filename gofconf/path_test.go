@@ -0,0 +1,60 @@
+/*******************************************************************************
+ * Copyright (c) 2018  charles
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NON INFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ * -------------------------------------------------------------------------
+ * created at 2026-11-07 17:31:06
+ ******************************************************************************/
+
+package gofconf
+
+import (
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestRegisterFlags(t *testing.T) {
+	dir := t.TempDir()
+	SetConfigFile("app.yaml")
+	defer func() {
+		confDirValue, confFileValue, confModeValue, confRemoteValue = "", "", "", ""
+		remoteValue = nil
+	}()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	RegisterFlags(fs)
+	err := fs.Parse([]string{"-gof.conf.dir", dir, "-gof.conf.mode", ModeBootstrap, "-gof.conf.remote", "http://127.0.0.1/conf.yaml"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := ConfigFile(), filepath.Join(dir, "app.yaml"); got != want {
+		t.Fatalf("config file %s, want %s", got, want)
+	}
+	if ConfigMode() != ModeBootstrap {
+		t.Fatalf("config mode %s", ConfigMode())
+	}
+	if r := RemoteConfig(); r == nil || r.URL != "http://127.0.0.1/conf.yaml" {
+		t.Fatalf("remote config %+v", r)
+	}
+	if flag.CommandLine.Lookup("gof.conf.dir") != nil {
+		t.Fatal("the flags are registered on flag.CommandLine at init")
+	}
+}
@@ -0,0 +1,302 @@
+/*******************************************************************************
+ * Copyright (c) 2018  charles
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NON INFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ * -------------------------------------------------------------------------
+ * created at 2026-10-28 09:26:40
+ ******************************************************************************/
+
+package gofconf
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The remote provider pulls the global configuration from an HTTP endpoint.
+// Every verified copy is written to a cache file which replaces the global file:
+// the cache file is watched like a local file, so the updates go through the same reload,
+// and the last good copy is used when the endpoint cannot be reached at startup.
+// A body must carry a valid HeaderConfigSignature when a signing key is set, a valid HeaderConfigChecksum
+// otherwise; Unverified opts out and accepts the bodies without either header.
+// The remote configuration is read-only, the bootstrap mode does not write it.
+// The URL is taken from SetRemote, the `-gof.conf.remote` flag (see RegisterFlags) or GOF_CONF_REMOTE.
+const (
+	// ConfRemoteEnv is the environment variable of the remote configuration URL
+	ConfRemoteEnv = "GOF_CONF_REMOTE"
+	// ConfRemoteKeyEnv is the environment variable of the HMAC key of the remote configuration
+	ConfRemoteKeyEnv = "GOF_CONF_REMOTE_KEY"
+	// HeaderConfigChecksum is the hex sha256 of the body, an optional "sha256=" prefix is allowed
+	HeaderConfigChecksum = "X-Config-Checksum"
+	// HeaderConfigSignature is the hex HMAC-SHA256 of the body
+	HeaderConfigSignature = "X-Config-Signature"
+	// DefaultRemoteInterval ...
+	DefaultRemoteInterval = 30 * time.Second
+)
+
+// Remote defines the remote configuration.
+type Remote struct {
+	URL string
+	// Format is yaml, json or toml. Optional. Default value the extension of the URL, or ConfigExt.
+	Format string
+	// Interval is the polling interval. Optional. Default value DefaultRemoteInterval.
+	Interval time.Duration
+	// SigningKey requires a valid HeaderConfigSignature. Optional. Default value GOF_CONF_REMOTE_KEY.
+	SigningKey string
+	// Unverified accepts the bodies without a checksum and a signature, a present checksum is always verified.
+	// Optional. Default value false, the configuration must be checksummed or signed.
+	Unverified bool
+	// CacheFile is the last good copy. Optional. Default value remote_cache.<format> in ConfigDir.
+	CacheFile string
+	// Header is added to the requests, e.g. an Authorization header.
+	Header http.Header
+	// Client. Optional. Default value a client with a timeout of 10 seconds.
+	Client *http.Client
+}
+
+var (
+	// confRemoteValue is set by the `-gof.conf.remote` flag
+	confRemoteValue string
+
+	remoteValue *Remote
+	remoteMu    sync.Mutex
+	remoteETag  string
+	remoteQuit  chan struct{}
+)
+
+// SetRemote sets the remote configuration, it must be called before Initialize.
+func SetRemote(r Remote) {
+	remoteValue = &r
+}
+
+// RemoteConfig returns the remote configuration, or nil when the configuration is local.
+func RemoteConfig() *Remote {
+	if remoteValue != nil && remoteValue.URL != "" {
+		return remoteValue
+	}
+	u := firstNotEmpty(confRemoteValue, os.Getenv(ConfRemoteEnv))
+	if u == "" {
+		return nil
+	}
+	remoteValue = &Remote{URL: u}
+	return remoteValue
+}
+
+// IsRemote reports whether the global configuration is pulled from a remote URL.
+func IsRemote() bool {
+	return RemoteConfig() != nil
+}
+
+func (r *Remote) format() string {
+	if r.Format != "" {
+		return strings.ToLower(r.Format)
+	}
+	if u, err := url.Parse(r.URL); err == nil {
+		if t, err := ConfigType(u.Path); err == nil {
+			return t
+		}
+	}
+	if t, err := ConfigType(ConfigFile()); err == nil {
+		return t
+	}
+	return "yaml"
+}
+
+// cacheFile returns the file replacing the global file.
+func (r *Remote) cacheFile() string {
+	if r.CacheFile != "" {
+		return r.CacheFile
+	}
+	return ConfigPath("remote_cache." + r.format())
+}
+
+func (r *Remote) client() *http.Client {
+	if r.Client != nil {
+		return r.Client
+	}
+	return &http.Client{Timeout: 10 * time.Second}
+}
+
+func (r *Remote) signingKey() string {
+	return firstNotEmpty(r.SigningKey, os.Getenv(ConfRemoteKeyEnv))
+}
+
+// fetch returns a nil body when the configuration is not modified.
+func (r *Remote) fetch(etag string) ([]byte, string, error) {
+	req, err := http.NewRequest(GET, r.URL, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	for k, v := range r.Header {
+		req.Header[k] = v
+	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	resp, err := r.client().Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, etag, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("remote config %s: %s", r.URL, resp.Status)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := r.verify(body, resp.Header); err != nil {
+		return nil, "", err
+	}
+	return body, resp.Header.Get("ETag"), nil
+}
+
+func (r *Remote) verify(body []byte, h http.Header) error {
+	sum := h.Get(HeaderConfigChecksum)
+	if sum != "" {
+		want, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(sum), "sha256="))
+		got := sha256.Sum256(body)
+		if err != nil || !hmac.Equal(want, got[:]) {
+			return fmt.Errorf("remote config %s: checksum mismatch", r.URL)
+		}
+	}
+	key := r.signingKey()
+	if key == "" {
+		if sum == "" && !r.Unverified {
+			return fmt.Errorf("remote config %s: no %s or signing key, set Remote.Unverified to accept it",
+				r.URL, HeaderConfigChecksum)
+		}
+		return nil
+	}
+	sig, err := hex.DecodeString(strings.TrimSpace(h.Get(HeaderConfigSignature)))
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write(body)
+	if err != nil || len(sig) == 0 || !hmac.Equal(sig, mac.Sum(nil)) {
+		return fmt.Errorf("remote config %s: invalid signature", r.URL)
+	}
+	return nil
+}
+
+// update fetches the configuration and replaces the cache file with a valid copy,
+// it reports whether the cache file changed.
+func (r *Remote) update() (bool, error) {
+	remoteMu.Lock()
+	defer remoteMu.Unlock()
+	body, etag, err := r.fetch(remoteETag)
+	if err != nil || body == nil {
+		return false, err
+	}
+	fileName := r.cacheFile()
+	if old, err := ioutil.ReadFile(fileName); err == nil && bytes.Equal(old, body) {
+		remoteETag = etag
+		return false, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(fileName), 0755); err != nil {
+		return false, err
+	}
+	ext := filepath.Ext(fileName)
+	tmp := filepath.Join(filepath.Dir(fileName), "."+strings.TrimSuffix(filepath.Base(fileName), ext)+".tmp"+ext)
+	if err := ioutil.WriteFile(tmp, body, 0600); err != nil {
+		return false, err
+	}
+	if err := Validate(tmp); err != nil {
+		os.Remove(tmp)
+		// the rejected copy is not fetched again until it changes
+		remoteETag = etag
+		return false, fmt.Errorf("remote config %s: %s", r.URL, err.Error())
+	}
+	if err := os.Rename(tmp, fileName); err != nil {
+		return false, err
+	}
+	remoteETag = etag
+	return true, nil
+}
+
+// startRemote pulls the configuration once, the cache file is used when it fails, then polls until Stop.
+func startRemote(r *Remote) error {
+	if _, err := r.update(); err != nil {
+		if _, e := os.Stat(r.cacheFile()); e != nil {
+			return err
+		}
+		log.Printf("config: %s, the cached copy %s is used\n", err.Error(), r.cacheFile())
+	}
+	remoteMu.Lock()
+	defer remoteMu.Unlock()
+	if remoteQuit != nil {
+		return nil
+	}
+	quit := make(chan struct{})
+	remoteQuit = quit
+	interval := r.Interval
+	if interval <= 0 {
+		interval = DefaultRemoteInterval
+	}
+	go func() {
+		tk := time.NewTicker(interval)
+		defer tk.Stop()
+		for {
+			select {
+			case <-quit:
+				return
+			case <-tk.C:
+				changed, err := r.update()
+				if err != nil {
+					log.Printf("config: %s\n", err.Error())
+					continue
+				}
+				if changed {
+					scheduleReload(SourceRemote)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// activeConfigFile returns the cache file of the remote configuration, or the global file.
+func activeConfigFile() string {
+	if r := RemoteConfig(); r != nil {
+		return r.cacheFile()
+	}
+	return ConfigFile()
+}
+
+func stopRemote() {
+	remoteMu.Lock()
+	defer remoteMu.Unlock()
+	if remoteQuit != nil {
+		close(remoteQuit)
+		remoteQuit = nil
+	}
+}
@@ -0,0 +1,164 @@
+/*******************************************************************************
+ * Copyright (c) 2018  charles
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NON INFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ * -------------------------------------------------------------------------
+ * created at 2026-11-06 14:05:27
+ ******************************************************************************/
+
+package gofconf
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+const remoteBody = "process:\n  listenport: 8200\n"
+
+func checksum(body string) string {
+	sum := sha256.Sum256([]byte(body))
+	return hex.EncodeToString(sum[:])
+}
+
+func signature(key, body string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(body))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// remoteServer serves body with the headers, it answers 304 to the matching If-None-Match
+func remoteServer(t *testing.T, body string, header http.Header) (*httptest.Server, *int32) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		etag := `"` + checksum(body)[:8] + `"`
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		for k, v := range header {
+			w.Header()[k] = v
+		}
+		w.Header().Set("ETag", etag)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+func newTestRemote(t *testing.T, url string) *Remote {
+	remoteETag = ""
+	return &Remote{URL: url + "/conf.yaml", CacheFile: filepath.Join(t.TempDir(), "cache.yaml")}
+}
+
+func readCache(t *testing.T, r *Remote) string {
+	b, err := ioutil.ReadFile(r.cacheFile())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestRemoteETag(t *testing.T) {
+	srv, requests := remoteServer(t, remoteBody, http.Header{HeaderConfigChecksum: {"sha256=" + checksum(remoteBody)}})
+	r := newTestRemote(t, srv.URL)
+	changed, err := r.update()
+	if err != nil || !changed {
+		t.Fatalf("first update: changed %v err %v", changed, err)
+	}
+	if got := readCache(t, r); got != remoteBody {
+		t.Fatalf("cache file %q", got)
+	}
+	changed, err = r.update()
+	if err != nil || changed {
+		t.Fatalf("not modified update: changed %v err %v", changed, err)
+	}
+	if n := atomic.LoadInt32(requests); n != 2 {
+		t.Fatalf("%d requests, want 2", n)
+	}
+}
+
+func TestRemoteVerify(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+		key    string
+		opt    bool
+		err    string
+	}{
+		{name: "checksum", header: http.Header{HeaderConfigChecksum: {checksum(remoteBody)}}},
+		{name: "checksum mismatch", header: http.Header{HeaderConfigChecksum: {checksum("x")}}, err: "checksum mismatch"},
+		{name: "unverified", err: "set Remote.Unverified"},
+		{name: "unverified opt-out", opt: true},
+		{name: "opt-out checks a present checksum", header: http.Header{HeaderConfigChecksum: {checksum("x")}}, opt: true, err: "checksum mismatch"},
+		{name: "signature", header: http.Header{HeaderConfigSignature: {signature("k", remoteBody)}}, key: "k"},
+		{name: "bad signature", header: http.Header{HeaderConfigSignature: {signature("other", remoteBody)}}, key: "k", err: "invalid signature"},
+		{name: "no signature", header: http.Header{HeaderConfigChecksum: {checksum(remoteBody)}}, key: "k", err: "invalid signature"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, _ := remoteServer(t, remoteBody, tt.header)
+			r := newTestRemote(t, srv.URL)
+			r.SigningKey, r.Unverified = tt.key, tt.opt
+			_, err := r.update()
+			if tt.err == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("err %v, want %q", err, tt.err)
+			}
+			if _, e := ioutil.ReadFile(r.cacheFile()); e == nil {
+				t.Fatal("a rejected copy was cached")
+			}
+		})
+	}
+}
+
+func TestRemoteCacheFallback(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+	r := newTestRemote(t, srv.URL)
+	if err := startRemote(r); err == nil {
+		stopRemote()
+		t.Fatal("started without the endpoint and without a cached copy")
+	}
+	if err := ioutil.WriteFile(r.cacheFile(), []byte(remoteBody), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := startRemote(r); err != nil {
+		t.Fatal(err)
+	}
+	stopRemote()
+	if got := readCache(t, r); got != remoteBody {
+		t.Fatalf("cache file %q", got)
+	}
+}
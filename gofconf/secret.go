@@ -0,0 +1,248 @@
+/*******************************************************************************
+ * Copyright (c) 2018  charles
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NON INFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ * -------------------------------------------------------------------------
+ * created at 2026-10-19 10:03:17
+ ******************************************************************************/
+
+package gofconf
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/atcharles/gof/openssl"
+)
+
+// Encrypted configuration values are written as `ENC(<openssl base64>)`
+// and decrypted transparently when the configuration is loaded.
+// String fields tagged with `secret:"true"` are encrypted when they are written back to the file.
+const (
+	// SecretKeyEnv is the environment variable holding the master key
+	SecretKeyEnv = "GOF_SECRET_KEY"
+	// SecretKeyFileEnv is the environment variable holding the path of the master key file
+	SecretKeyFileEnv = "GOF_SECRET_KEY_FILE"
+	// SecretKeyFileName is the master key file looked up in the configuration directory
+	SecretKeyFileName = ".secret_key"
+
+	encPrefix = "ENC("
+	encSuffix = ")"
+)
+
+// ErrSecretKeyNotFound is returned when an encrypted value is found but no master key is configured.
+var ErrSecretKeyNotFound = errors.New("secret key not found, set " + SecretKeyEnv + " or " + SecretKeyFileEnv)
+
+// SecretKey returns the master key from $GOF_SECRET_KEY, the file named by $GOF_SECRET_KEY_FILE,
+// or the `.secret_key` file in the configuration directory.
+func SecretKey() (string, error) {
+	if key := os.Getenv(SecretKeyEnv); key != "" {
+		return key, nil
+	}
+	fileName := os.Getenv(SecretKeyFileEnv)
+	if fileName == "" {
+		fileName = ConfigPath(SecretKeyFileName)
+	}
+	b, err := ioutil.ReadFile(fileName)
+	if os.IsNotExist(err) {
+		return "", ErrSecretKeyNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	key := strings.TrimSpace(string(b))
+	if key == "" {
+		return "", ErrSecretKeyNotFound
+	}
+	return key, nil
+}
+
+// IsEncrypted reports whether the value has the form `ENC(...)`.
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, encPrefix) && strings.HasSuffix(value, encSuffix)
+}
+
+// EncryptValue encrypts a value with the master key,
+// the result can be pasted into the configuration file as it is.
+func EncryptValue(plain string) (string, error) {
+	key, err := SecretKey()
+	if err != nil {
+		return "", err
+	}
+	return encryptValue(key, plain)
+}
+
+func encryptValue(key, plain string) (string, error) {
+	b, err := openssl.New().EncryptString(key, plain)
+	if err != nil {
+		return "", err
+	}
+	return encPrefix + string(b) + encSuffix, nil
+}
+
+// DecryptValue decrypts a value of the form `ENC(...)`, any other value is returned unchanged.
+func DecryptValue(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+	key, err := SecretKey()
+	if err != nil {
+		return "", err
+	}
+	return decryptValue(key, value)
+}
+
+func decryptValue(key, value string) (string, error) {
+	data := strings.TrimSuffix(strings.TrimPrefix(value, encPrefix), encSuffix)
+	b, err := openssl.New().DecryptString(key, data)
+	if err != nil {
+		return "", fmt.Errorf("decrypt config value: %s", err.Error())
+	}
+	return string(b), nil
+}
+
+// DecryptSecrets decrypts every `ENC(...)` string reachable from ptr in place.
+func DecryptSecrets(ptr interface{}) error {
+	return walkStrings(reflect.ValueOf(ptr), false, func(value string, secret bool) (string, error) {
+		return DecryptValue(value)
+	})
+}
+
+// EncryptSecrets returns a copy of the struct ptr points to,
+// in which the string fields tagged with `secret:"true"` are encrypted.
+// ptr itself is returned when no master key is configured.
+func EncryptSecrets(ptr interface{}) (interface{}, error) {
+	key, err := SecretKey()
+	if err == ErrSecretKeyNotFound {
+		return ptr, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	v := reflect.ValueOf(ptr)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return ptr, nil
+	}
+	cp := reflect.New(v.Elem().Type())
+	cp.Elem().Set(v.Elem())
+	// Only plain string fields are encrypted, they are not shared with the original after the copy.
+	err = walkFields(cp.Elem(), false, func(value string, secret bool) (string, error) {
+		if !secret || value == "" || IsEncrypted(value) {
+			return value, nil
+		}
+		return encryptValue(key, value)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cp.Interface(), nil
+}
+
+func isSecretField(field reflect.StructField) bool {
+	return field.Tag.Get("secret") == "true"
+}
+
+// walkFields calls fn for the string fields of the struct v and of its nested struct values.
+func walkFields(v reflect.Value, secret bool, fn func(value string, secret bool) (string, error)) error {
+	switch v.Kind() {
+	case reflect.String:
+		if !v.CanSet() {
+			return nil
+		}
+		s, err := fn(v.String(), secret)
+		if err != nil {
+			return err
+		}
+		v.SetString(s)
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			if t.Field(i).PkgPath != "" {
+				continue
+			}
+			if err := walkFields(v.Field(i), isSecretField(t.Field(i)), fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// walkStrings calls fn for every settable string reachable from v,
+// following pointers, slices, arrays and maps.
+func walkStrings(v reflect.Value, secret bool, fn func(value string, secret bool) (string, error)) error {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		return walkStrings(v.Elem(), secret, fn)
+	case reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		if v.Elem().Kind() != reflect.String || !v.CanSet() {
+			return walkStrings(v.Elem(), secret, fn)
+		}
+		s, err := fn(v.Elem().String(), secret)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(s))
+	case reflect.String:
+		if !v.CanSet() {
+			return nil
+		}
+		s, err := fn(v.String(), secret)
+		if err != nil {
+			return err
+		}
+		v.SetString(s)
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			if t.Field(i).PkgPath != "" {
+				continue
+			}
+			if err := walkStrings(v.Field(i), isSecretField(t.Field(i)), fn); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := walkStrings(v.Index(i), secret, fn); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		for _, k := range v.MapKeys() {
+			e := reflect.New(v.Type().Elem()).Elem()
+			e.Set(v.MapIndex(k))
+			if err := walkStrings(e, secret, fn); err != nil {
+				return err
+			}
+			v.SetMapIndex(k, e)
+		}
+	}
+	return nil
+}
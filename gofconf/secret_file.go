@@ -0,0 +1,133 @@
+/*******************************************************************************
+ * Copyright (c) 2018  charles
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NON INFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ * -------------------------------------------------------------------------
+ * created at 2026-10-19 15:07:29
+ ******************************************************************************/
+
+package gofconf
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// A configuration field can be read from a file, e.g. a Docker or Kubernetes secret mount:
+// either with the environment variable of the field suffixed with `_FILE`,
+// e.g. GOF_REDIS_PASSWORD_FILE=/run/secrets/redis,
+// or with a `file://` value in the configuration file, e.g. `password: file:///run/secrets/redis`.
+// The files are watched and the configuration is reloaded when they change.
+const (
+	fileEnvSuffix = "_FILE"
+	filePrefix    = "file://"
+)
+
+var (
+	secretMu      sync.Mutex
+	secretWatcher *fsnotify.Watcher
+	secretFiles   = make(map[string]bool)
+	secretDirs    = make(map[string]bool)
+)
+
+// ResolveFiles replaces every `file://` string reachable from ptr with the content of the file.
+func ResolveFiles(ptr interface{}) error {
+	return walkStrings(reflect.ValueOf(ptr), false, func(value string, secret bool) (string, error) {
+		if !strings.HasPrefix(value, filePrefix) {
+			return value, nil
+		}
+		return readSecretFile(strings.TrimPrefix(value, filePrefix))
+	})
+}
+
+// readSecretFile reads a value from a file without the trailing line break, and watches the file.
+func readSecretFile(fileName string) (string, error) {
+	fileName, err := filepath.Abs(fileName)
+	if err != nil {
+		return "", err
+	}
+	b, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return "", fmt.Errorf("read config value: %s", err.Error())
+	}
+	if err := watchSecretFile(fileName); err != nil {
+		log.Printf("watch %s err:%s\n", fileName, err.Error())
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
+
+// watchSecretFile watches the directory of the file,
+// Kubernetes updates a secret mount by swapping the `..data` symlink.
+func watchSecretFile(fileName string) error {
+	secretMu.Lock()
+	defer secretMu.Unlock()
+	if secretFiles[fileName] {
+		return nil
+	}
+	if secretWatcher == nil {
+		w, err := fsnotify.NewWatcher()
+		if err != nil {
+			return err
+		}
+		secretWatcher = w
+		go watchSecretEvents(w)
+	}
+	dir := filepath.Dir(fileName)
+	if !secretDirs[dir] {
+		if err := secretWatcher.Add(dir); err != nil {
+			return err
+		}
+		secretDirs[dir] = true
+	}
+	secretFiles[fileName] = true
+	return nil
+}
+
+func watchSecretEvents(w *fsnotify.Watcher) {
+	for {
+		select {
+		case e, ok := <-w.Events:
+			if !ok {
+				return
+			}
+			if e.Op == fsnotify.Chmod {
+				continue
+			}
+			name := filepath.Clean(e.Name)
+			secretMu.Lock()
+			changed := secretFiles[name] || filepath.Base(name) == "..data"
+			secretMu.Unlock()
+			if changed {
+				scheduleReload(SourceSecretFile)
+			}
+		case err, ok := <-w.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("secret file watcher err:%s\n", err.Error())
+		}
+	}
+}
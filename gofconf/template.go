@@ -0,0 +1,541 @@
+/*******************************************************************************
+ * Copyright (c) 2018  charles
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NON INFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ * -------------------------------------------------------------------------
+ * created at 2026-10-20 09:41:16
+ ******************************************************************************/
+
+package gofconf
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/atcharles/gof/gofutils"
+	"github.com/atcharles/gof/gofutils/errors"
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v2"
+)
+
+// The configuration of the registered objects is rendered in the declaration order of the fields.
+// The `comment` tag of a field is written above it in the yaml and toml formats, a field without
+// the tag gets a comment made of its name and type, json has no comments.
+// An object implementing `Validate() error` is checked by Validate.
+
+// Redacted replaces the values of the secret fields in Effective.
+const Redacted = "******"
+
+type confNode struct {
+	key      string
+	comment  string
+	value    interface{}
+	children []*confNode
+}
+
+func objKey(obj interface{}) string {
+	return gofutils.SnakeString(gofutils.ObjectName(obj))
+}
+
+// fieldKey is the key viper reads and writes for the field.
+func fieldKey(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("mapstructure"), ",")[0]
+	if name == "" {
+		name = strings.ToLower(field.Name)
+	}
+	return name
+}
+
+func sectionNode(key string, v reflect.Value) *confNode {
+	for v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	return &confNode{key: key, comment: v.Type().String(), children: buildNodes(v)}
+}
+
+func buildNodes(v reflect.Value) []*confNode {
+	nodes := make([]*confNode, 0)
+	t := v.Type()
+	for i := 0; i < v.NumField(); i++ {
+		field := t.Field(i)
+		key := fieldKey(field)
+		if field.PkgPath != "" || key == "-" {
+			continue
+		}
+		n := &confNode{key: key, comment: fieldComment(field)}
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Ptr && fv.Type().Elem().Kind() == reflect.Struct {
+			if fv.IsNil() {
+				fv = reflect.New(fv.Type().Elem())
+			}
+			fv = fv.Elem()
+		}
+		if fv.Kind() == reflect.Struct {
+			n.children = buildNodes(fv)
+		} else {
+			n.value = plainValue(fv)
+		}
+		nodes = append(nodes, n)
+	}
+	return nodes
+}
+
+// fieldComment returns the `comment` tag of the field or a comment made of its name and type,
+// the secret fields tell how they are encrypted.
+func fieldComment(field reflect.StructField) string {
+	comment := field.Tag.Get("comment")
+	if comment == "" {
+		comment = strings.Replace(gofutils.SnakeString(field.Name), "_", " ", -1)
+		switch {
+		case field.Type == durationType:
+			comment += ", a duration e.g. 30s"
+		case field.Type.Kind() == reflect.Slice:
+			comment += ", a list"
+		case field.Type.Kind() == reflect.Map:
+			comment += ", a map"
+		}
+	}
+	if field.Tag.Get("secret") == "true" {
+		comment += "\nsecret, write it encrypted as ENC(...), see `config encrypt`"
+	}
+	return comment
+}
+
+// plainValue is the value as it is written to a file, durations are written as `30s`.
+func plainValue(v reflect.Value) interface{} {
+	if v.Type() == durationType {
+		return time.Duration(v.Int()).String()
+	}
+	if v.Kind() == reflect.Slice && v.IsNil() {
+		return reflect.MakeSlice(v.Type(), 0, 0).Interface()
+	}
+	return v.Interface()
+}
+
+func renderNodes(format string, nodes []*confNode) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	var err error
+	switch format {
+	case "yaml", "yml":
+		err = writeYAML(buf, nodes, 0)
+	case "toml":
+		err = writeTOML(buf, nodes, "")
+	case "json":
+		err = writeJSON(buf, nodes, "")
+		buf.WriteString("\n")
+	default:
+		err = fmt.Errorf("unsupported config format: %s", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeComment(buf *bytes.Buffer, indent, comment string) {
+	if comment == "" {
+		return
+	}
+	for _, line := range strings.Split(comment, "\n") {
+		fmt.Fprintf(buf, "%s# %s\n", indent, line)
+	}
+}
+
+func writeYAML(buf *bytes.Buffer, nodes []*confNode, depth int) error {
+	indent := strings.Repeat("  ", depth)
+	for i, n := range nodes {
+		if depth == 0 && i > 0 {
+			buf.WriteString("\n")
+		}
+		writeComment(buf, indent, n.comment)
+		if n.children != nil {
+			if len(n.children) == 0 {
+				fmt.Fprintf(buf, "%s%s: {}\n", indent, n.key)
+				continue
+			}
+			fmt.Fprintf(buf, "%s%s:\n", indent, n.key)
+			if err := writeYAML(buf, n.children, depth+1); err != nil {
+				return err
+			}
+			continue
+		}
+		b, err := yaml.Marshal(map[string]interface{}{n.key: n.value})
+		if err != nil {
+			return err
+		}
+		for _, line := range strings.Split(strings.TrimRight(string(b), "\n"), "\n") {
+			fmt.Fprintf(buf, "%s%s\n", indent, line)
+		}
+	}
+	return nil
+}
+
+// writeTOML writes the values of a table before its sub tables, as toml requires.
+func writeTOML(buf *bytes.Buffer, nodes []*confNode, table string) error {
+	for _, n := range nodes {
+		if n.children != nil {
+			continue
+		}
+		value, err := tomlValue(reflect.ValueOf(n.value))
+		if err != nil {
+			return fmt.Errorf("%s: %s", n.key, err.Error())
+		}
+		writeComment(buf, "", n.comment)
+		fmt.Fprintf(buf, "%s = %s\n", n.key, value)
+	}
+	for _, n := range nodes {
+		if n.children == nil {
+			continue
+		}
+		name := n.key
+		if table != "" {
+			name = table + "." + n.key
+		}
+		if buf.Len() > 0 {
+			buf.WriteString("\n")
+		}
+		writeComment(buf, "", n.comment)
+		fmt.Fprintf(buf, "[%s]\n", name)
+		if err := writeTOML(buf, n.children, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func tomlValue(v reflect.Value) (string, error) {
+	if !v.IsValid() {
+		return `""`, nil
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return `""`, nil
+		}
+		return tomlValue(v.Elem())
+	case reflect.String, reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+		// json strings and numbers are valid toml values
+		b, err := json.Marshal(v.Interface())
+		return string(b), err
+	case reflect.Slice, reflect.Array:
+		items := make([]string, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			item, err := tomlValue(v.Index(i))
+			if err != nil {
+				return "", err
+			}
+			items = append(items, item)
+		}
+		return "[" + strings.Join(items, ", ") + "]", nil
+	case reflect.Map:
+		items := make([]string, 0, v.Len())
+		for _, k := range v.MapKeys() {
+			item, err := tomlValue(v.MapIndex(k))
+			if err != nil {
+				return "", err
+			}
+			items = append(items, fmt.Sprintf("%v = %s", k.Interface(), item))
+		}
+		sort.Strings(items)
+		return "{ " + strings.Join(items, ", ") + " }", nil
+	}
+	return "", fmt.Errorf("unsupported toml value type %s", v.Type())
+}
+
+func writeJSON(buf *bytes.Buffer, nodes []*confNode, indent string) error {
+	buf.WriteString("{\n")
+	for i, n := range nodes {
+		key, _ := json.Marshal(n.key)
+		fmt.Fprintf(buf, "%s  %s: ", indent, key)
+		if n.children != nil {
+			if err := writeJSON(buf, n.children, indent+"  "); err != nil {
+				return err
+			}
+		} else {
+			b, err := json.MarshalIndent(n.value, indent+"  ", "  ")
+			if err != nil {
+				return err
+			}
+			buf.Write(b)
+		}
+		if i < len(nodes)-1 {
+			buf.WriteString(",")
+		}
+		buf.WriteString("\n")
+	}
+	buf.WriteString(indent + "}")
+	return nil
+}
+
+// Template renders the registered defaults of every object in the format yaml, json or toml,
+// neither the loaded configuration nor the environment is used and the secret fields are left empty.
+func Template(format string) ([]byte, error) {
+	nodes := make([]*confNode, 0, len(innerFuncGroup))
+	for _, obj := range innerFuncGroup {
+		value := defaultOf(obj)
+		err := walkStrings(reflect.ValueOf(value), false, func(value string, secret bool) (string, error) {
+			if secret {
+				return "", nil
+			}
+			return value, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, sectionNode(objKey(obj), reflect.ValueOf(value)))
+	}
+	return renderNodes(format, nodes)
+}
+
+// Effective renders, in the format yaml, json or toml, the configuration the program would use with
+// the file: its values, the environment overrides and the `file://` values, with the secret fields redacted.
+// Missing sections use the defaults.
+func Effective(fileName, format string) ([]byte, error) {
+	vp, err := readConfigFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	nodes := make([]*confNode, 0, len(innerFuncGroup))
+	for _, obj := range innerFuncGroup {
+		value, err := loadObj(vp, obj, false)
+		if err != nil {
+			return nil, err
+		}
+		if err := redactSecrets(value); err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, sectionNode(objKey(obj), reflect.ValueOf(value)))
+	}
+	return renderNodes(format, nodes)
+}
+
+// Validate checks the file against the registered objects without applying it:
+// unknown fields, values of the wrong type, values which cannot be read or decrypted
+// and the Validate method of the objects.
+func Validate(fileName string) error {
+	vp, err := readConfigFile(fileName)
+	if err != nil {
+		return err
+	}
+	return validateConfig(vp)
+}
+
+func validateConfig(vp *viper.Viper) error {
+	var errs error
+	for _, obj := range innerFuncGroup {
+		if _, err := loadObj(vp, obj, true); err != nil {
+			errs = errors.Append(errs, fmt.Errorf("%s: %s", objKey(obj), err.Error()))
+		}
+	}
+	return errs
+}
+
+// SetValue sets one key of the file, e.g. `process.listenport`, and writes the file.
+// The value is converted to the type of the field, with encrypt it is written as `ENC(...)`.
+// The file is rewritten by viper, its comments are not kept.
+func SetValue(fileName, key, value string, encrypt bool) error {
+	vp, err := readConfigFile(fileName)
+	if err != nil {
+		return err
+	}
+	typed, err := typedValue(key, value)
+	if err != nil {
+		return err
+	}
+	if encrypt {
+		if _, ok := typed.(string); !ok {
+			return fmt.Errorf("%s is not a string, it cannot be encrypted", key)
+		}
+		if typed, err = EncryptValue(value); err != nil {
+			return err
+		}
+	}
+	vp.Set(key, typed)
+	return vp.WriteConfig()
+}
+
+// typedValue converts the value to the type of the field key names in the registered objects,
+// keys of unknown sections are kept as strings.
+func typedValue(key, value string) (interface{}, error) {
+	parts := strings.Split(strings.ToLower(key), ".")
+	var v reflect.Value
+	for _, obj := range innerFuncGroup {
+		if objKey(obj) == parts[0] {
+			v = reflect.ValueOf(obj).Elem()
+		}
+	}
+	if !v.IsValid() {
+		return value, nil
+	}
+	for _, part := range parts[1:] {
+		for v.Kind() == reflect.Ptr {
+			v = reflect.New(v.Type().Elem()).Elem()
+		}
+		if v.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("unknown config key: %s", key)
+		}
+		t, found := v.Type(), false
+		for i := 0; i < t.NumField(); i++ {
+			if t.Field(i).PkgPath == "" && strings.ToLower(fieldKey(t.Field(i))) == part {
+				v, found = v.Field(i), true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown config key: %s", key)
+		}
+	}
+	if v.Kind() == reflect.Struct {
+		return nil, fmt.Errorf("%s is a section, set its fields", key)
+	}
+	nv := reflect.New(v.Type()).Elem()
+	if err := setEnvValue(nv, value); err != nil {
+		return nil, fmt.Errorf("%s: %s", key, err.Error())
+	}
+	return plainValue(nv), nil
+}
+
+func readConfigFile(fileName string) (*viper.Viper, error) {
+	configType, err := ConfigType(fileName)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(fileName); err != nil {
+		return nil, err
+	}
+	vp := viper.New()
+	vp.SetConfigType(configType)
+	vp.SetConfigFile(fileName)
+	if err := vp.ReadInConfig(); err != nil {
+		return nil, err
+	}
+	return vp, nil
+}
+
+// readConfigContent reads content in the format of fileName, the file itself is not read.
+func readConfigContent(fileName string, content []byte) (*viper.Viper, error) {
+	configType, err := ConfigType(fileName)
+	if err != nil {
+		return nil, err
+	}
+	vp := viper.New()
+	vp.SetConfigType(configType)
+	if err := vp.ReadConfig(bytes.NewReader(content)); err != nil {
+		return nil, err
+	}
+	return vp, nil
+}
+
+// loadObj reads the section of obj from vp into a copy of obj, obj itself is not changed.
+// strict reports unknown fields and calls the Validate method of the object.
+func loadObj(vp *viper.Viper, obj Init, strict bool) (interface{}, error) {
+	key := objKey(obj)
+	ptr := deepCopy(reflect.ValueOf(obj)).Interface()
+	if vp.IsSet(key) {
+		if err := decodeValue(vp.Get(key), ptr, strict); err != nil {
+			return nil, err
+		}
+	}
+	if err := ResolveObj(EnvName(key), ptr); err != nil {
+		return nil, err
+	}
+	if v, ok := ptr.(interface {
+		Validate() error
+	}); ok && strict {
+		if err := v.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return ptr, nil
+}
+
+// decodeValue decodes like viper.UnmarshalKey does.
+func decodeValue(input, ptr interface{}, strict bool) error {
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook: mapstructure.ComposeDecodeHookFunc(
+			mapstructure.StringToTimeDurationHookFunc(),
+			mapstructure.StringToSliceHookFunc(","),
+		),
+		ErrorUnused:      strict,
+		WeaklyTypedInput: true,
+		Result:           ptr,
+	})
+	if err != nil {
+		return err
+	}
+	return decoder.Decode(input)
+}
+
+func redactSecrets(ptr interface{}) error {
+	return walkStrings(reflect.ValueOf(ptr), false, func(value string, secret bool) (string, error) {
+		if secret && value != "" {
+			return Redacted, nil
+		}
+		return value, nil
+	})
+}
+
+// deepCopy copies pointers, structs, slices and maps, the copy shares nothing with v.
+func deepCopy(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		cp := reflect.New(v.Type().Elem())
+		cp.Elem().Set(deepCopy(v.Elem()))
+		return cp
+	case reflect.Struct:
+		cp := reflect.New(v.Type()).Elem()
+		cp.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if cp.Field(i).CanSet() {
+				cp.Field(i).Set(deepCopy(v.Field(i)))
+			}
+		}
+		return cp
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		cp := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			cp.Index(i).Set(deepCopy(v.Index(i)))
+		}
+		return cp
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		cp := reflect.MakeMap(v.Type())
+		for _, k := range v.MapKeys() {
+			cp.SetMapIndex(k, deepCopy(v.MapIndex(k)))
+		}
+		return cp
+	}
+	return v
+}
@@ -0,0 +1,168 @@
+/*******************************************************************************
+ * Copyright (c) 2018  charles
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NON INFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ * -------------------------------------------------------------------------
+ * created at 2026-10-19 09:12:41
+ ******************************************************************************/
+
+package gofconf
+
+import (
+	"log"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+var (
+	// ReloadDelay is how long the configuration file must stay quiet before it is reloaded.
+	// Editors usually fire several fsnotify events for a single save.
+	ReloadDelay = 300 * time.Millisecond
+
+	reloadMu     sync.Mutex
+	reloadTimer  *time.Timer
+	reloadSource string
+	stopped      bool
+	applyMu      sync.Mutex
+
+	subMu       sync.Mutex
+	subscribers = make(map[string][]ChangeFunc)
+	applied     = make(map[string]interface{})
+	loaded      bool
+)
+
+// ChangeFunc is called with the previous and the current value of a subscribed key.
+type ChangeFunc func(key string, oldValue, newValue interface{})
+
+// Subscribe registers fn for one configuration key, e.g. `cors_config`.
+// fn is only called after a reload in which the value of that subtree actually changed.
+func Subscribe(key string, fn ChangeFunc) {
+	key = strings.ToLower(key)
+	subMu.Lock()
+	defer subMu.Unlock()
+	subscribers[key] = append(subscribers[key], fn)
+	if _, ok := applied[key]; !ok && loaded {
+		applied[key] = viper.Get(key)
+	}
+}
+
+// scheduleReload (re)starts the debounce timer, so a burst of events results in a single reload.
+// The source is recorded in the history, a source other than SourceWatch wins within a burst.
+func scheduleReload(source string) {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+	if stopped {
+		return
+	}
+	if reloadSource == "" || reloadSource == SourceWatch {
+		reloadSource = source
+	}
+	if reloadTimer == nil {
+		reloadTimer = time.AfterFunc(ReloadDelay, reload)
+		return
+	}
+	reloadTimer.Reset(ReloadDelay)
+}
+
+// reload reads the configuration file again, runs every InitFunc and notifies the subscribers.
+func reload() {
+	reloadMu.Lock()
+	source := reloadSource
+	reloadSource = ""
+	reloadMu.Unlock()
+	reloadFrom(source)
+}
+
+// reloadFrom reads the configuration file again and records the new version.
+func reloadFrom(source string) {
+	if err := reloadWith(source, "", nil, viper.ReadInConfig); err != nil {
+		log.Println(err.Error())
+	}
+}
+
+// reloadWith reads the configuration with read, runs every InitFunc, records the version
+// and notifies the subscribers. The version is the content, the configuration file when content is nil.
+func reloadWith(source, fileName string, content []byte, read func() error) error {
+	applyMu.Lock()
+	defer applyMu.Unlock()
+	if err := read(); err != nil {
+		return err
+	}
+	for _, c := range innerFuncGroup {
+		if err := c.InitFunc(); err != nil {
+			log.Println(err.Error())
+		}
+	}
+	runLoadHooks()
+	if content == nil {
+		recordVersion(source)
+	} else {
+		recordContent(source, fileName, content)
+	}
+	notifySubscribers()
+	return nil
+}
+
+// snapshotSubscribed records the current value of every subscribed key without notifying anyone.
+func snapshotSubscribed() {
+	subMu.Lock()
+	defer subMu.Unlock()
+	for key := range subscribers {
+		applied[key] = viper.Get(key)
+	}
+	loaded = true
+}
+
+func notifySubscribers() {
+	type change struct {
+		key      string
+		old, new interface{}
+		fns      []ChangeFunc
+	}
+	changes := make([]change, 0)
+	subMu.Lock()
+	for key, fns := range subscribers {
+		nv := viper.Get(key)
+		ov := applied[key]
+		if reflect.DeepEqual(ov, nv) {
+			continue
+		}
+		applied[key] = nv
+		changes = append(changes, change{key: key, old: ov, new: nv, fns: fns})
+	}
+	subMu.Unlock()
+	for _, c := range changes {
+		for _, fn := range c.fns {
+			callSubscriber(fn, c.key, c.old, c.new)
+		}
+	}
+}
+
+func callSubscriber(fn ChangeFunc, key string, oldValue, newValue interface{}) {
+	defer func() {
+		if p := recover(); p != nil {
+			log.Printf("config subscriber of %s panic: %v\n", key, p)
+		}
+	}()
+	fn(key, oldValue, newValue)
+}
@@ -0,0 +1,128 @@
+/*******************************************************************************
+ * Copyright (c) 2018  charles
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NON INFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ * -------------------------------------------------------------------------
+ * created at 2026-11-08 09:26:14
+ ******************************************************************************/
+
+package gofconf
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// loadTestConfig reads content as the configuration file like Start, without the watcher
+func loadTestConfig(t *testing.T, content string) string {
+	fileName := filepath.Join(t.TempDir(), "conf.yaml")
+	writeConf(t, fileName, content)
+	viper.SetConfigFile(fileName)
+	if err := viper.ReadInConfig(); err != nil {
+		t.Fatal(err)
+	}
+	resetHistory(t, "")
+	snapshotSubscribed()
+	delay := ReloadDelay
+	ReloadDelay = 50 * time.Millisecond
+	t.Cleanup(func() {
+		ReloadDelay = delay
+		subMu.Lock()
+		subscribers = make(map[string][]ChangeFunc)
+		applied = make(map[string]interface{})
+		loaded = false
+		subMu.Unlock()
+		viper.Reset()
+	})
+	return fileName
+}
+
+// changeRecorder records the calls of a ChangeFunc
+type changeRecorder struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (r *changeRecorder) fn(key string, oldValue, newValue interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, fmt.Sprint(newValue))
+}
+
+func (r *changeRecorder) get() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.calls...)
+}
+
+func TestReloadDebounce(t *testing.T) {
+	fileName := loadTestConfig(t, "process:\n  listenport: 8200\nredis:\n  db: 1\n")
+	process, redis := new(changeRecorder), new(changeRecorder)
+	Subscribe("Process", process.fn)
+	Subscribe("redis", redis.fn)
+
+	writeConf(t, fileName, "process:\n  listenport: 8300\nredis:\n  db: 1\n")
+	scheduleReload(SourceWatch)
+	for i := 0; i < 5; i++ {
+		time.Sleep(10 * time.Millisecond)
+		scheduleReload(SourceWatch)
+	}
+	scheduleReload(SourceRemote)
+	scheduleReload(SourceWatch)
+	time.Sleep(10 * ReloadDelay)
+
+	calls := process.get()
+	if len(calls) != 1 || !strings.Contains(calls[0], "8300") {
+		t.Fatalf("process subscriber calls %v, want one with the new port", calls)
+	}
+	if calls := redis.get(); len(calls) != 0 {
+		t.Fatalf("the unchanged redis section was notified: %v", calls)
+	}
+	history := History()
+	if n := len(history); n != 1 || history[0].Source != SourceRemote {
+		t.Fatalf("history %+v, want one remote version", history)
+	}
+
+	// a reload without a change notifies no one
+	scheduleReload(SourceWatch)
+	time.Sleep(10 * ReloadDelay)
+	if calls := process.get(); len(calls) != 1 {
+		t.Fatalf("process subscriber calls %v after an unchanged reload", calls)
+	}
+}
+
+func TestSubscriberPanic(t *testing.T) {
+	fileName := loadTestConfig(t, "process:\n  listenport: 8200\n")
+	after := new(changeRecorder)
+	Subscribe("process", func(key string, oldValue, newValue interface{}) {
+		panic("subscriber failure")
+	})
+	Subscribe("process", after.fn)
+	writeConf(t, fileName, "process:\n  listenport: 8300\n")
+	reloadFrom(SourceWatch)
+	if calls := after.get(); len(calls) != 1 {
+		t.Fatalf("calls %v, a panicking subscriber must not stop the others", calls)
+	}
+}
@@ -0,0 +1,467 @@
+/*******************************************************************************
+ * Copyright (c) 2018  charles
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NON INFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ * -------------------------------------------------------------------------
+ * created at 2026-10-23 10:02:17
+ ******************************************************************************/
+
+// Package gofcron is a scheduler of named jobs with the 6-field specs of robfig/cron
+// ("sec min hour dom month dow" or @every, @daily...).
+// A singleton job runs on one instance per tick, the instances share a lock in gofcache,
+// and a job is skipped while its previous run is not finished unless it allows overlap.
+package gofcron
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/atcharles/gof/gofcache"
+	"github.com/atcharles/gof/gofutils"
+	"github.com/atcharles/gof/gofutils/errors"
+	"github.com/robfig/cron"
+)
+
+const (
+	// DefaultHistorySize is the number of runs kept for every job
+	DefaultHistorySize = 20
+	// DefaultLockTTL is the time a singleton job holds the cluster lock when it does not finish
+	DefaultLockTTL = time.Hour
+	// LockPrefix is the prefix of the lock keys in the cache
+	LockPrefix = "gofcron:"
+)
+
+// Status of a run
+const (
+	StatusOK      = "ok"
+	StatusError   = "error"
+	StatusSkipped = "skipped"
+)
+
+var (
+	// ErrJobNotFound ...
+	ErrJobNotFound = errors.New("cron job not found")
+	// ErrJobExists ...
+	ErrJobExists = errors.New("cron job already exists")
+	// ErrRunning is returned by Trigger when the job is running and does not allow overlap
+	ErrRunning = errors.New("cron job is running")
+
+	// Default is the scheduler of the package functions
+	Default = New(nil)
+)
+
+// Job ...
+type Job struct {
+	Name string
+	Spec string
+	Func func(ctx context.Context) error
+	// Singleton runs the job on one instance per tick,
+	// an @every schedule is aligned on the clock so that the instances share its ticks
+	Singleton bool
+	// AllowOverlap runs the job even when the previous run is not finished
+	AllowOverlap bool
+	// Timeout cancels the context of the run, 0 means no timeout
+	Timeout time.Duration
+	// LockTTL see DefaultLockTTL
+	LockTTL time.Duration
+}
+
+// Run is a record of the history.
+type Run struct {
+	Name     string        `json:"name"`
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration"`
+	Status   string        `json:"status"`
+	Error    string        `json:"error,omitempty"`
+	// Triggered is true when the run was started by Trigger
+	Triggered bool `json:"triggered"`
+}
+
+// alignedSchedule fires every delay from the zero time, unlike cron.ConstantDelaySchedule
+// which fires every delay from the start of the scheduler.
+type alignedSchedule struct {
+	delay time.Duration
+}
+
+func (a alignedSchedule) Next(t time.Time) time.Time {
+	return t.Truncate(a.delay).Add(a.delay)
+}
+
+// Info describes a job.
+type Info struct {
+	Name    string    `json:"name"`
+	Spec    string    `json:"spec"`
+	Paused  bool      `json:"paused"`
+	Running bool      `json:"running"`
+	Next    time.Time `json:"next"`
+	Prev    time.Time `json:"prev"`
+	LastRun *Run      `json:"last_run,omitempty"`
+}
+
+type entry struct {
+	s        *Scheduler
+	job      Job
+	schedule cron.Schedule
+	// last is the latest tick, see tick
+	last    time.Time
+	mu      sync.Mutex
+	paused  bool
+	running int
+	history []Run
+}
+
+// Scheduler ...
+type Scheduler struct {
+	// HistorySize see DefaultHistorySize
+	HistorySize int
+
+	cron    *cron.Cron
+	locker  gofcache.Locker
+	token   string
+	mu      sync.RWMutex
+	entries map[string]*entry
+	started bool
+	wg      sync.WaitGroup
+}
+
+// New creates a scheduler, the singleton jobs are locked by locker, gofcache.DefLocker() when nil.
+func New(locker gofcache.Locker) *Scheduler {
+	host, _ := os.Hostname()
+	return &Scheduler{
+		HistorySize: DefaultHistorySize,
+		cron:        cron.New(),
+		locker:      locker,
+		token:       host + ":" + strconv.Itoa(os.Getpid()),
+		entries:     make(map[string]*entry),
+	}
+}
+
+// Add registers the job, the spec is checked here.
+func (s *Scheduler) Add(job Job) error {
+	if job.Name == "" || job.Func == nil {
+		return errors.New("cron job needs a name and a func")
+	}
+	schedule, err := cron.Parse(job.Spec)
+	if err != nil {
+		return fmt.Errorf("cron job %s: %s", job.Name, err.Error())
+	}
+	if job.LockTTL <= 0 {
+		job.LockTTL = DefaultLockTTL
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.entries[job.Name]; ok {
+		return ErrJobExists
+	}
+	if every, ok := schedule.(cron.ConstantDelaySchedule); ok && job.Singleton {
+		schedule = alignedSchedule{delay: every.Delay}
+	}
+	e := &entry{s: s, job: job, schedule: schedule, last: time.Now()}
+	s.entries[job.Name] = e
+	s.cron.Schedule(schedule, e)
+	return nil
+}
+
+// AddFunc registers a job without options.
+func (s *Scheduler) AddFunc(name, spec string, fn func(ctx context.Context) error) error {
+	return s.Add(Job{Name: name, Spec: spec, Func: fn})
+}
+
+// Start runs the scheduler until Stop.
+func (s *Scheduler) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.started {
+		s.started = true
+		s.cron.Start()
+	}
+	return nil
+}
+
+// Stop stops the scheduler and waits for the running jobs until ctx is done.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	if s.started {
+		s.started = false
+		s.cron.Stop()
+	}
+	s.mu.Unlock()
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Pause skips the scheduled runs of the job, Trigger still runs it.
+func (s *Scheduler) Pause(name string) error {
+	return s.setPaused(name, true)
+}
+
+// Resume ...
+func (s *Scheduler) Resume(name string) error {
+	return s.setPaused(name, false)
+}
+
+func (s *Scheduler) setPaused(name string, paused bool) error {
+	e, err := s.entry(name)
+	if err != nil {
+		return err
+	}
+	e.mu.Lock()
+	e.paused = paused
+	e.mu.Unlock()
+	return nil
+}
+
+// Trigger runs the job now and waits for the result, the singleton lock of the tick is not used.
+func (s *Scheduler) Trigger(ctx context.Context, name string) (Run, error) {
+	e, err := s.entry(name)
+	if err != nil {
+		return Run{}, err
+	}
+	run := e.run(ctx, true)
+	if run.Status == StatusSkipped {
+		return run, ErrRunning
+	}
+	return run, nil
+}
+
+// History returns the recent runs of the job, the latest first.
+func (s *Scheduler) History(name string) ([]Run, error) {
+	e, err := s.entry(name)
+	if err != nil {
+		return nil, err
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	runs := make([]Run, len(e.history))
+	for i, r := range e.history {
+		runs[len(runs)-1-i] = r
+	}
+	return runs, nil
+}
+
+// Jobs returns the jobs sorted by name.
+func (s *Scheduler) Jobs() []Info {
+	next := make(map[*entry]*cron.Entry)
+	for _, ce := range s.cron.Entries() {
+		if e, ok := ce.Job.(*entry); ok {
+			next[e] = ce
+		}
+	}
+	s.mu.RLock()
+	infos := make([]Info, 0, len(s.entries))
+	for _, e := range s.entries {
+		e.mu.Lock()
+		info := Info{
+			Name:    e.job.Name,
+			Spec:    e.job.Spec,
+			Paused:  e.paused,
+			Running: e.running > 0,
+		}
+		if n := len(e.history); n > 0 {
+			last := e.history[n-1]
+			info.LastRun = &last
+		}
+		e.mu.Unlock()
+		if ce := next[e]; ce != nil {
+			info.Next, info.Prev = ce.Next, ce.Prev
+		}
+		infos = append(infos, info)
+	}
+	s.mu.RUnlock()
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name < infos[j].Name
+	})
+	return infos
+}
+
+func (s *Scheduler) entry(name string) (*entry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	e, ok := s.entries[name]
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+	return e, nil
+}
+
+func (s *Scheduler) getLocker() gofcache.Locker {
+	if s.locker != nil {
+		return s.locker
+	}
+	return gofcache.DefLocker()
+}
+
+// Run is called by cron on every tick.
+func (e *entry) Run() {
+	e.mu.Lock()
+	paused := e.paused
+	e.mu.Unlock()
+	if paused {
+		return
+	}
+	if e.job.Singleton {
+		// the fire time names the tick on every instance, the lock is not released
+		// so that a late instance skips the tick, it expires at the next tick
+		now := time.Now()
+		tick, next := e.tick(now)
+		key := fmt.Sprintf("%s%s:%d", LockPrefix, e.job.Name, tick.Unix())
+		ttl := next.Sub(now)
+		if ttl < time.Second {
+			ttl = time.Second
+		}
+		ok, err := e.s.getLocker().Lock(key, e.s.token, ttl)
+		if err != nil {
+			e.record(Run{Name: e.job.Name, Start: time.Now(), Status: StatusError, Error: err.Error()})
+			return
+		}
+		if !ok {
+			return
+		}
+	}
+	e.run(context.Background(), false)
+}
+
+// tick returns the latest fire time of the schedule at now and the next one.
+// cron fires at the fire time of the local clock, so the instances agree on the tick whatever their skew.
+func (e *entry) tick(now time.Time) (tick, next time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	tick = e.last
+	for next = e.schedule.Next(tick); !next.IsZero() && !next.After(now); next = e.schedule.Next(tick) {
+		tick = next
+	}
+	e.last = tick
+	return tick, next
+}
+
+func (e *entry) run(ctx context.Context, triggered bool) Run {
+	run := Run{Name: e.job.Name, Start: time.Now(), Triggered: triggered}
+	if !e.begin() {
+		run.Status = StatusSkipped
+		run.Error = "previous run is not finished"
+		e.record(run)
+		return run
+	}
+	defer e.end()
+
+	if e.job.Singleton && !e.job.AllowOverlap {
+		key := LockPrefix + e.job.Name + ":running"
+		locker := e.s.getLocker()
+		ok, err := locker.Lock(key, e.s.token, e.job.LockTTL)
+		if err != nil {
+			run.Status, run.Error = StatusError, err.Error()
+			e.record(run)
+			return run
+		}
+		if !ok {
+			run.Status = StatusSkipped
+			run.Error = "running on another instance"
+			e.record(run)
+			return run
+		}
+		defer func() {
+			if err := locker.Unlock(key, e.s.token); err != nil {
+				log.Printf("gofcron unlock %s err:%s\n", e.job.Name, err.Error())
+			}
+		}()
+	}
+
+	if e.job.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.job.Timeout)
+		defer cancel()
+	}
+	err := e.call(ctx)
+	run.Duration = time.Since(run.Start)
+	run.Status = StatusOK
+	if err != nil {
+		run.Status, run.Error = StatusError, err.Error()
+		log.SetFlags(log.LstdFlags)
+		log.Printf("gofcron job %s err:%s\n", e.job.Name, err.Error())
+	}
+	e.record(run)
+	return run
+}
+
+func (e *entry) begin() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.running > 0 && !e.job.AllowOverlap {
+		return false
+	}
+	e.running++
+	e.s.wg.Add(1)
+	return true
+}
+
+func (e *entry) end() {
+	e.mu.Lock()
+	e.running--
+	e.mu.Unlock()
+	e.s.wg.Done()
+}
+
+func (e *entry) call(ctx context.Context) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			log.SetFlags(log.LstdFlags)
+			log.Println(string(gofutils.PanicTrace(4)))
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return e.job.Func(ctx)
+}
+
+func (e *entry) record(run Run) {
+	size := e.s.HistorySize
+	if size <= 0 {
+		size = DefaultHistorySize
+	}
+	e.mu.Lock()
+	e.history = append(e.history, run)
+	if n := len(e.history); n > size {
+		e.history = append(e.history[:0], e.history[n-size:]...)
+	}
+	e.mu.Unlock()
+}
+
+// Add registers the job in Default.
+func Add(job Job) error {
+	return Default.Add(job)
+}
+
+// AddFunc registers a job without options in Default.
+func AddFunc(name, spec string, fn func(ctx context.Context) error) error {
+	return Default.AddFunc(name, spec, fn)
+}
@@ -0,0 +1,168 @@
+/*******************************************************************************
+ * Copyright (c) 2018  charles
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NON INFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ * -------------------------------------------------------------------------
+ * created at 2026-11-08 11:24:52
+ ******************************************************************************/
+
+package gofcron
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/atcharles/gof/gofcache"
+)
+
+// mapLocker is the cache shared by the instances of a test, gofcache.NewMemoryCache is shared by the whole process
+type mapLocker struct {
+	mu   sync.Mutex
+	keys map[string]string
+}
+
+func newLocker() *mapLocker {
+	return &mapLocker{keys: make(map[string]string)}
+}
+
+func (l *mapLocker) Lock(key, token string, ttl time.Duration) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.keys[key]; ok {
+		return false, nil
+	}
+	l.keys[key] = token
+	return true, nil
+}
+
+func (l *mapLocker) Unlock(key, token string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.keys[key] == token {
+		delete(l.keys, key)
+	}
+	return nil
+}
+
+// addTestJob adds job to a new scheduler sharing locker, as if it ran on another instance
+func addTestJob(t *testing.T, locker gofcache.Locker, job Job) *entry {
+	s := New(locker)
+	if err := s.Add(job); err != nil {
+		t.Fatal(err)
+	}
+	e, err := s.entry(job.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestSingletonOneInstancePerTick(t *testing.T) {
+	locker := newLocker()
+	var runs int32
+	job := Job{Name: "report", Spec: "@every 1h", Singleton: true, Func: func(ctx context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		return nil
+	}}
+	instances := []*entry{addTestJob(t, locker, job), addTestJob(t, locker, job), addTestJob(t, locker, job)}
+	// the instances started at different times, they fire on the same aligned tick
+	for i, e := range instances {
+		e.last = time.Now().Add(-time.Duration(i+2) * time.Hour)
+	}
+	for _, e := range instances {
+		e.Run()
+	}
+	if n := atomic.LoadInt32(&runs); n != 1 {
+		t.Fatalf("%d runs, want one per tick", n)
+	}
+}
+
+func TestSingletonRunningOnAnotherInstance(t *testing.T) {
+	locker := newLocker()
+	started, release := make(chan struct{}), make(chan struct{})
+	var calls int32
+	job := Job{Name: "sync", Spec: "@every 1m", Singleton: true, Func: func(ctx context.Context) error {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			close(started)
+			<-release
+		}
+		return nil
+	}}
+	a, b := addTestJob(t, locker, job), addTestJob(t, locker, job)
+	done := make(chan Run, 1)
+	go func() {
+		done <- a.run(context.Background(), true)
+	}()
+	<-started
+	run, err := b.s.Trigger(context.Background(), "sync")
+	if err != ErrRunning || run.Status != StatusSkipped || run.Error != "running on another instance" {
+		t.Fatalf("trigger on another instance: %+v %v", run, err)
+	}
+	close(release)
+	if run := <-done; run.Status != StatusOK {
+		t.Fatalf("unexpected run %+v", run)
+	}
+	// the lock is released at the end of the run
+	if run, err := b.s.Trigger(context.Background(), "sync"); err != nil || run.Status != StatusOK {
+		t.Fatalf("trigger after the run: %+v %v", run, err)
+	}
+}
+
+func TestOverlapSkipped(t *testing.T) {
+	s := New(newLocker())
+	started, release := make(chan struct{}), make(chan struct{})
+	var calls int32
+	err := s.AddFunc("slow", "@every 1m", func(ctx context.Context) error {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			close(started)
+			<-release
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go s.Trigger(context.Background(), "slow")
+	<-started
+	if _, err := s.Trigger(context.Background(), "slow"); err != ErrRunning {
+		t.Fatalf("trigger during a run: %v, want %v", err, ErrRunning)
+	}
+	close(release)
+	if err := s.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	runs, _ := s.History("slow")
+	if len(runs) != 2 || runs[0].Status != StatusOK || runs[1].Status != StatusSkipped {
+		t.Fatalf("history %+v, want a skipped run then an ok one", runs)
+	}
+}
+
+func TestPanicRecorded(t *testing.T) {
+	s := New(newLocker())
+	s.AddFunc("panic", "@every 1m", func(ctx context.Context) error {
+		panic("job failure")
+	})
+	run, err := s.Trigger(context.Background(), "panic")
+	if err != nil || run.Status != StatusError || run.Error != "panic: job failure" {
+		t.Fatalf("unexpected run %+v %v", run, err)
+	}
+}
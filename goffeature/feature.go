@@ -0,0 +1,222 @@
+/*******************************************************************************
+ * Copyright (c) 2018  charles
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NON INFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ * -------------------------------------------------------------------------
+ * created at 2026-10-27 10:45:31
+ ******************************************************************************/
+
+// Package goffeature evaluates the feature flags declared in the features section of the config,
+// the flags are updated when gofconf reloads the file.
+// The names are case-insensitive, viper reads the keys of the file in lower case.
+//
+//	features:
+//	  flags:
+//	    new_checkout:
+//	      enabled: true
+//	      percentage: 20
+//	      users: ["42"]
+//	      tenants: ["acme"]
+package goffeature
+
+import (
+	"hash/fnv"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/atcharles/gof/gofconf"
+)
+
+type (
+	// Flag ...
+	Flag struct {
+		Enabled    bool     `mapstructure:"enabled" yaml:"enabled" comment:"off for everyone when false"`
+		Percentage int      `mapstructure:"percentage" yaml:"percentage" comment:"share of the users (0-100) having the flag on, hashed on the user ID"`
+		Users      []string `mapstructure:"users" yaml:"users" comment:"users having the flag on"`
+		Tenants    []string `mapstructure:"tenants" yaml:"tenants" comment:"tenants having the flag on"`
+	}
+
+	// Features is the config section of the flags.
+	Features struct {
+		Flags map[string]Flag `mapstructure:"flags" yaml:"flags" comment:"an enabled flag without percentage, users and tenants is on for everyone"`
+	}
+
+	// Subject is who a flag is evaluated for.
+	Subject struct {
+		UserID   string
+		TenantID string
+	}
+
+	// Counter counts the evaluations of a flag.
+	Counter struct {
+		On  uint64 `json:"on"`
+		Off uint64 `json:"off"`
+	}
+
+	compiled struct {
+		Flag
+		users   map[string]bool
+		tenants map[string]bool
+	}
+
+	counter struct {
+		on  uint64
+		off uint64
+	}
+)
+
+var (
+	// DefaultFeatures holds the flags read from the config
+	DefaultFeatures = Features{Flags: map[string]Flag{}}
+
+	mu       sync.RWMutex
+	flags    = make(map[string]*compiled)
+	countMu  sync.Mutex
+	counters = make(map[string]*counter)
+)
+
+func init() {
+	gofconf.AddDefaultInformation(&DefaultFeatures)
+}
+
+// InitFunc ReadIn ...
+// The section is read into a new value, so the flags removed from the file are removed.
+func (p *Features) InitFunc() error {
+	next := Features{Flags: map[string]Flag{}}
+	if err := gofconf.ReadObjInformation(&next); err != nil {
+		return err
+	}
+	Set(next)
+	return nil
+}
+
+// Set replaces the flags, it is called on every reload of the config.
+// The names are stored in lower case.
+func Set(f Features) {
+	next := make(map[string]*compiled, len(f.Flags))
+	for name, flag := range f.Flags {
+		next[strings.ToLower(name)] = &compiled{Flag: flag, users: toSet(flag.Users), tenants: toSet(flag.Tenants)}
+	}
+	mu.Lock()
+	DefaultFeatures = f
+	flags = next
+	mu.Unlock()
+}
+
+func toSet(list []string) map[string]bool {
+	set := make(map[string]bool, len(list))
+	for _, s := range list {
+		set[s] = true
+	}
+	return set
+}
+
+// Enabled evaluates the flag for the subject, an unknown flag is off.
+// The name is case-insensitive.
+func Enabled(name string, s Subject) bool {
+	name = strings.ToLower(name)
+	mu.RLock()
+	c := flags[name]
+	mu.RUnlock()
+	on := c != nil && c.eval(name, s)
+	count(name, on)
+	return on
+}
+
+// Evaluate evaluates every flag for the subject.
+func Evaluate(s Subject) map[string]bool {
+	mu.RLock()
+	all := flags
+	mu.RUnlock()
+	out := make(map[string]bool, len(all))
+	for name, c := range all {
+		on := c.eval(name, s)
+		count(name, on)
+		out[name] = on
+	}
+	return out
+}
+
+// Names returns the declared flags, sorted.
+func Names() []string {
+	mu.RLock()
+	names := make([]string, 0, len(flags))
+	for name := range flags {
+		names = append(names, name)
+	}
+	mu.RUnlock()
+	sort.Strings(names)
+	return names
+}
+
+func (c *compiled) eval(name string, s Subject) bool {
+	if !c.Enabled {
+		return false
+	}
+	if c.Percentage <= 0 && len(c.users) == 0 && len(c.tenants) == 0 {
+		return true
+	}
+	if (s.UserID != "" && c.users[s.UserID]) || (s.TenantID != "" && c.tenants[s.TenantID]) {
+		return true
+	}
+	if c.Percentage >= 100 {
+		return true
+	}
+	if c.Percentage <= 0 || s.UserID == "" {
+		return false
+	}
+	return bucket(name, s.UserID) < uint32(c.Percentage)
+}
+
+// bucket is stable for a user and a flag, and differs between the flags.
+func bucket(name, userID string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	h.Write([]byte{':'})
+	h.Write([]byte(userID))
+	return h.Sum32() % 100
+}
+
+func count(name string, on bool) {
+	countMu.Lock()
+	c := counters[name]
+	if c == nil {
+		c = new(counter)
+		counters[name] = c
+	}
+	countMu.Unlock()
+	if on {
+		atomic.AddUint64(&c.on, 1)
+	} else {
+		atomic.AddUint64(&c.off, 1)
+	}
+}
+
+// Counters returns the evaluation counters by flag, the unknown flags included.
+func Counters() map[string]Counter {
+	countMu.Lock()
+	defer countMu.Unlock()
+	out := make(map[string]Counter, len(counters))
+	for name, c := range counters {
+		out[name] = Counter{On: atomic.LoadUint64(&c.on), Off: atomic.LoadUint64(&c.off)}
+	}
+	return out
+}
@@ -0,0 +1,104 @@
+/*******************************************************************************
+ * Copyright (c) 2018  charles
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NON INFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ * -------------------------------------------------------------------------
+ * created at 2026-10-27 11:30:12
+ ******************************************************************************/
+
+package goffeature
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ContextKey is the key of the flags of the request in the gin context.
+const ContextKey = "features"
+
+// requestFlags evaluates the flags of a request when the handler asks for them, once per flag,
+// so the counters count the flags used rather than the requests.
+type requestFlags struct {
+	subject Subject
+	// flags are the flags when the request started, a reload does not change them during the request
+	flags map[string]*compiled
+
+	mu     sync.Mutex
+	values map[string]bool
+}
+
+func (r *requestFlags) enabled(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if on, ok := r.values[name]; ok {
+		return on
+	}
+	c := r.flags[name]
+	on := c != nil && c.eval(name, r.subject)
+	count(name, on)
+	r.values[name] = on
+	return on
+}
+
+// Middleware stores the subject of the request in the context, the flags are evaluated
+// by IsEnabled and FromContext. A nil subject func evaluates the flags without a user.
+func Middleware(subject func(c *gin.Context) Subject) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := &requestFlags{values: make(map[string]bool)}
+		if subject != nil {
+			r.subject = subject(c)
+		}
+		mu.RLock()
+		r.flags = flags
+		mu.RUnlock()
+		c.Set(ContextKey, r)
+		c.Next()
+	}
+}
+
+func fromContext(c *gin.Context) *requestFlags {
+	if v, ok := c.Get(ContextKey); ok {
+		if r, ok := v.(*requestFlags); ok {
+			return r
+		}
+	}
+	return nil
+}
+
+// FromContext evaluates every flag for the request, nil without Middleware.
+func FromContext(c *gin.Context) map[string]bool {
+	r := fromContext(c)
+	if r == nil {
+		return nil
+	}
+	out := make(map[string]bool, len(r.flags))
+	for name := range r.flags {
+		out[name] = r.enabled(name)
+	}
+	return out
+}
+
+// IsEnabled evaluates the flag for the request, an unknown flag is off. The flag is evaluated once per request.
+// The name is case-insensitive like in Enabled.
+func IsEnabled(c *gin.Context, name string) bool {
+	r := fromContext(c)
+	return r != nil && r.enabled(strings.ToLower(name))
+}
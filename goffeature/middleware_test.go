@@ -0,0 +1,74 @@
+/*******************************************************************************
+ * Copyright (c) 2018  charles
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NON INFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ * -------------------------------------------------------------------------
+ * created at 2026-11-07 19:12:40
+ ******************************************************************************/
+
+package goffeature
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMiddlewareEvaluatesOnDemand(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	Set(Features{Flags: map[string]Flag{
+		"asked":  {Enabled: true, Users: []string{"42"}},
+		"unused": {Enabled: true},
+	}})
+	countMu.Lock()
+	counters = make(map[string]*counter)
+	countMu.Unlock()
+
+	r := gin.New()
+	r.Use(Middleware(func(c *gin.Context) Subject {
+		return Subject{UserID: c.Query("user")}
+	}))
+	r.GET("/", func(c *gin.Context) {
+		if IsEnabled(c, "Asked") != IsEnabled(c, "asked") {
+			t.Error("the flag changed during the request")
+		}
+		if IsEnabled(c, "asked") {
+			c.String(http.StatusOK, "on")
+			return
+		}
+		c.String(http.StatusOK, "off")
+	})
+	for _, user := range []string{"42", "7", "42"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/?user="+user, nil))
+		want := map[bool]string{true: "on", false: "off"}[user == "42"]
+		if w.Body.String() != want {
+			t.Fatalf("user %s: got %q, want %q", user, w.Body.String(), want)
+		}
+	}
+	got := Counters()
+	if got["asked"] != (Counter{On: 2, Off: 1}) {
+		t.Fatalf("asked counter %+v, want one evaluation per request", got["asked"])
+	}
+	if _, ok := got["unused"]; ok {
+		t.Fatalf("the unused flag was evaluated: %+v", got)
+	}
+}
@@ -0,0 +1,110 @@
+/*******************************************************************************
+ * Copyright (c) 2018  charles
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NON INFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ * -------------------------------------------------------------------------
+ * created at 2026-10-25 14:40:53
+ ******************************************************************************/
+
+package gofi18n
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/atcharles/gof/gofconf"
+)
+
+// Code is an application error code, it must not change once clients rely on it.
+type Code struct {
+	Code   int
+	Status int
+	Key    string
+}
+
+var (
+	codesMu sync.RWMutex
+	codes   = make(map[int]*Code)
+)
+
+// Register adds a code to the catalog with its HTTP status, message key and default translations by language.
+// It panics when the code is registered twice, like http.Handle.
+func Register(code, status int, key string, translations map[string]string) *Code {
+	codesMu.Lock()
+	defer codesMu.Unlock()
+	if c, ok := codes[code]; ok {
+		panic(fmt.Sprintf("gofi18n: code %d is already registered with %s", code, c.Key))
+	}
+	for lang, msg := range translations {
+		AddTranslations(lang, map[string]string{key: msg})
+	}
+	c := &Code{Code: code, Status: status, Key: key}
+	codes[code] = c
+	return c
+}
+
+// Lookup ...
+func Lookup(code int) (*Code, bool) {
+	codesMu.RLock()
+	defer codesMu.RUnlock()
+	c, ok := codes[code]
+	return c, ok
+}
+
+// Codes returns the catalog sorted by code.
+func Codes() []*Code {
+	codesMu.RLock()
+	list := make([]*Code, 0, len(codes))
+	for _, c := range codes {
+		list = append(list, c)
+	}
+	codesMu.RUnlock()
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Code < list[j].Code
+	})
+	return list
+}
+
+// New returns the error of the code, the message is translated for the request by the error handler.
+func (c *Code) New(args ...interface{}) *gofconf.HTTPError {
+	return &gofconf.HTTPError{
+		Code:       c.Status,
+		Message:    Translate(DefaultI18n.DefaultLanguage, c.Key, args...),
+		BizCode:    c.Code,
+		MessageKey: c.Key,
+		Args:       args,
+	}
+}
+
+// Wrap returns the error of the code holding err as the internal error.
+func (c *Code) Wrap(err error, args ...interface{}) *gofconf.HTTPError {
+	return c.New(args...).WithInternal(err)
+}
+
+// Localize returns a copy of the error with the message of MessageKey in lang,
+// the errors without a message key are returned as is.
+func Localize(he *gofconf.HTTPError, lang string) *gofconf.HTTPError {
+	if he.MessageKey == "" {
+		return he
+	}
+	c := *he
+	c.Message = Translate(lang, he.MessageKey, he.Args...)
+	return &c
+}
@@ -0,0 +1,261 @@
+/*******************************************************************************
+ * Copyright (c) 2018  charles
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NON INFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ * -------------------------------------------------------------------------
+ * created at 2026-10-25 11:17:26
+ ******************************************************************************/
+
+// Package gofi18n translates message keys with the YAML files of the config dir
+// and keeps the catalog of the application error codes, see Register.
+//
+// A translation file is named <lang>.yaml or <name>.<lang>.yaml, the nested keys are joined by dots:
+//
+//	errors:
+//	  user_not_found: "user %v not found"
+package gofi18n
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/atcharles/gof/gofconf"
+	"gopkg.in/yaml.v2"
+)
+
+type (
+	// I18n defines the config of the translations.
+	I18n struct {
+		Dir             string `mapstructure:"dir" yaml:"dir" comment:"directory of the translation files <lang>.yaml, relative to the config dir"`
+		DefaultLanguage string `mapstructure:"default_language" yaml:"default_language" comment:"language used when Accept-Language matches no translation"`
+	}
+)
+
+var (
+	// DefaultI18n ...
+	DefaultI18n = I18n{Dir: "i18n", DefaultLanguage: "en"}
+
+	mu sync.RWMutex
+	// loaded holds the translations of the files, defaults the ones of Register
+	loaded   = make(map[string]map[string]string)
+	defaults = make(map[string]map[string]string)
+)
+
+func init() {
+	gofconf.AddDefaultInformation(&DefaultI18n)
+}
+
+// InitFunc ReadIn ...
+func (p *I18n) InitFunc() error {
+	if err := gofconf.ReadObjInformation(&DefaultI18n); err != nil {
+		return err
+	}
+	return Load(DefaultI18n.Path())
+}
+
+// Path returns the translation directory, a relative Dir is in the config dir.
+func (p *I18n) Path() string {
+	if filepath.IsAbs(p.Dir) {
+		return p.Dir
+	}
+	return gofconf.ConfigPath(p.Dir)
+}
+
+// Load replaces the translations with the YAML files of dir, a missing dir is not an error.
+func Load(dir string) error {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			files = nil
+		} else {
+			return err
+		}
+	}
+	next := make(map[string]map[string]string)
+	for _, fi := range files {
+		ext := filepath.Ext(fi.Name())
+		if fi.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		name := strings.TrimSuffix(fi.Name(), ext)
+		lang := normalize(name[strings.LastIndex(name, ".")+1:])
+		b, err := ioutil.ReadFile(filepath.Join(dir, fi.Name()))
+		if err != nil {
+			return err
+		}
+		var tree map[interface{}]interface{}
+		if err := yaml.Unmarshal(b, &tree); err != nil {
+			return fmt.Errorf("%s: %s", fi.Name(), err.Error())
+		}
+		if next[lang] == nil {
+			next[lang] = make(map[string]string)
+		}
+		flatten(next[lang], "", tree)
+	}
+	mu.Lock()
+	loaded = next
+	mu.Unlock()
+	return nil
+}
+
+func flatten(out map[string]string, prefix string, tree map[interface{}]interface{}) {
+	for k, v := range tree {
+		key := fmt.Sprint(k)
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		if sub, ok := v.(map[interface{}]interface{}); ok {
+			flatten(out, key, sub)
+			continue
+		}
+		out[key] = fmt.Sprint(v)
+	}
+}
+
+// AddTranslations adds the default translations of lang, the files override them.
+func AddTranslations(lang string, messages map[string]string) {
+	lang = normalize(lang)
+	mu.Lock()
+	defer mu.Unlock()
+	if defaults[lang] == nil {
+		defaults[lang] = make(map[string]string)
+	}
+	for k, v := range messages {
+		defaults[lang][k] = v
+	}
+}
+
+func lookup(lang, key string) (string, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	if msg, ok := loaded[lang][key]; ok {
+		return msg, true
+	}
+	msg, ok := defaults[lang][key]
+	return msg, ok
+}
+
+// Translate returns the message of key in lang, formatted with args.
+// The default language is used when lang has no message, and the key when no language has one.
+func Translate(lang, key string, args ...interface{}) string {
+	msg, ok := lookup(normalize(lang), key)
+	if !ok {
+		msg, ok = lookup(normalize(DefaultI18n.DefaultLanguage), key)
+	}
+	if !ok {
+		msg = key
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(msg, args...)
+	}
+	return msg
+}
+
+// Languages returns the languages having a translation, sorted.
+func Languages() []string {
+	mu.RLock()
+	set := make(map[string]bool)
+	for lang := range loaded {
+		set[lang] = true
+	}
+	for lang := range defaults {
+		set[lang] = true
+	}
+	mu.RUnlock()
+	langs := make([]string, 0, len(set))
+	for lang := range set {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// Match returns the language of Languages preferred by the Accept-Language header,
+// "zh" matches "zh-cn" and "zh-tw" matches "zh". DefaultLanguage is returned when none matches.
+func Match(acceptLanguage string) string {
+	langs := Languages()
+	for _, tag := range parseAccept(acceptLanguage) {
+		if tag == "*" {
+			break
+		}
+		base := tag
+		if i := strings.Index(tag, "-"); i > 0 {
+			base = tag[:i]
+		}
+		var found string
+		for _, lang := range langs {
+			if lang == tag {
+				return lang
+			}
+			if found == "" && (lang == base || strings.HasPrefix(lang, tag+"-")) {
+				found = lang
+			}
+		}
+		if found != "" {
+			return found
+		}
+	}
+	return normalize(DefaultI18n.DefaultLanguage)
+}
+
+// parseAccept returns the tags of the header sorted by quality.
+func parseAccept(header string) []string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+	var tags []weighted
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		tag := normalize(fields[0])
+		if tag == "" {
+			continue
+		}
+		q := 1.0
+		for _, f := range fields[1:] {
+			f = strings.TrimSpace(f)
+			if strings.HasPrefix(f, "q=") {
+				if v, err := strconv.ParseFloat(f[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		if q > 0 {
+			tags = append(tags, weighted{tag, q})
+		}
+	}
+	sort.SliceStable(tags, func(i, j int) bool {
+		return tags[i].q > tags[j].q
+	})
+	out := make([]string, len(tags))
+	for i, t := range tags {
+		out[i] = t.tag
+	}
+	return out
+}
+
+func normalize(lang string) string {
+	return strings.ToLower(strings.Replace(strings.TrimSpace(lang), "_", "-", -1))
+}
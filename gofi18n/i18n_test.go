@@ -0,0 +1,110 @@
+/*******************************************************************************
+ * Copyright (c) 2018  charles
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NON INFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ * -------------------------------------------------------------------------
+ * created at 2026-11-08 14:12:37
+ ******************************************************************************/
+
+package gofi18n
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/atcharles/gof/gofconf"
+)
+
+// loadFiles loads the translation files until the end of the test
+func loadFiles(t *testing.T, files map[string]string) {
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := Load(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		Load(filepath.Join(dir, "missing"))
+	})
+}
+
+func TestMatch(t *testing.T) {
+	loadFiles(t, map[string]string{
+		"en.yaml":            "hello: hello\n",
+		"fr.yaml":            "hello: bonjour\n",
+		"zh.yaml":            "hello: 你好\n",
+		"messages.pt_BR.yml": "hello: olá\n",
+	})
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"fr", "fr"},
+		{"FR-ca,fr;q=0.9", "fr"},
+		{"zh-TW", "zh"},
+		{"zh_tw", "zh"},
+		{"pt", "pt-br"},
+		{"pt-BR", "pt-br"},
+		{"de;q=1, fr;q=0.5", "fr"},
+		{"fr;q=0.2, zh;q=0.8", "zh"},
+		{"fr;q=0", "en"},
+		{"de", "en"},
+		{"*", "en"},
+		{"", "en"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			if got := Match(tt.header); got != tt.want {
+				t.Fatalf("Match(%q) = %q, want %q", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	AddTranslations("en", map[string]string{"errors.user_not_found": "no user %v", "errors.builtin": "built in"})
+	loadFiles(t, map[string]string{
+		"en.yaml": "errors:\n  user_not_found: user %v not found\n",
+		"fr.yaml": "errors:\n  user_not_found: utilisateur %v introuvable\n",
+	})
+	tests := []struct {
+		lang, key string
+		args      []interface{}
+		want      string
+	}{
+		{"fr", "errors.user_not_found", []interface{}{42}, "utilisateur 42 introuvable"},
+		{"en", "errors.user_not_found", []interface{}{42}, "user 42 not found"},
+		{"fr", "errors.builtin", nil, "built in"},
+		{"de", "errors.unknown", nil, "errors.unknown"},
+	}
+	for _, tt := range tests {
+		if got := Translate(tt.lang, tt.key, tt.args...); got != tt.want {
+			t.Fatalf("Translate(%s, %s) = %q, want %q", tt.lang, tt.key, got, tt.want)
+		}
+	}
+
+	he := &gofconf.HTTPError{Code: 404, Message: "Not Found", MessageKey: "errors.user_not_found", Args: []interface{}{7}}
+	if got := Localize(he, "fr"); got.Message != "utilisateur 7 introuvable" || he.Message != "Not Found" {
+		t.Fatalf("localized %v, original %v", got.Message, he.Message)
+	}
+}
@@ -0,0 +1,393 @@
+/*******************************************************************************
+ * Copyright (c) 2018  charles
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NON INFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ * -------------------------------------------------------------------------
+ * created at 2026-11-02 09:36:21
+ ******************************************************************************/
+
+package goflogger
+
+import (
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/atcharles/gof/gofconf"
+	"github.com/atcharles/gof/gofutils/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// policies of a full buffer
+const (
+	FullBlock      = "block"
+	FullDropNewest = "drop_newest"
+	FullDropLevel  = "drop_level"
+)
+
+// DefaultBatchSize is the number of entries taken at once from the buffer by the async writer
+var DefaultBatchSize = 256
+
+// asyncBatchBytes is the size of the writes of the async writer, a larger entry is written alone
+const asyncBatchBytes = 64 * 1024
+
+// AsyncOptions ...
+type AsyncOptions struct {
+	// Size is the number of entries of the buffer
+	Size int
+	// BatchSize defaults to DefaultBatchSize
+	BatchSize int
+	// FlushInterval is the maximum time an entry stays in the batch, it defaults to 1s
+	FlushInterval time.Duration
+	// Policy is FullBlock, FullDropNewest or FullDropLevel
+	Policy string
+	// DropLevel is the most severe level dropped by FullDropLevel
+	DropLevel logrus.Level
+}
+
+// ConfigAsyncOptions returns the options of gofconf.DefaultLog
+func ConfigAsyncOptions() AsyncOptions {
+	c := gofconf.DefaultLog
+	opts := AsyncOptions{
+		Size:          c.AsyncBuffer,
+		FlushInterval: c.FlushInterval,
+		Policy:        strings.ToLower(c.FullPolicy),
+		DropLevel:     logrus.InfoLevel,
+	}
+	if l, err := logrus.ParseLevel(c.DropLevel); err == nil {
+		opts.DropLevel = l
+	}
+	return opts
+}
+
+// AsyncStats is a snapshot of the counters of an AsyncWriter
+type AsyncStats struct {
+	Buffered int
+	Written  uint64
+	// Dropped counts the dropped entries by level
+	Dropped map[string]uint64
+}
+
+type asyncEntry struct {
+	level logrus.Level
+	p     []byte
+}
+
+// AsyncWriter writes the entries in the background through a bounded ring buffer,
+// the entries are written in batches and flushed at least every FlushInterval.
+type AsyncWriter struct {
+	written uint64
+
+	out  io.Writer
+	opts AsyncOptions
+
+	mu      sync.Mutex
+	notFull *sync.Cond
+	ring    []asyncEntry
+	head    int
+	count   int
+	closed  bool
+	dropped map[logrus.Level]uint64
+
+	wake    chan struct{}
+	flushes chan chan struct{}
+	quit    chan struct{}
+	done    chan struct{}
+}
+
+// NewAsyncWriter starts the writer of out, see Close
+func NewAsyncWriter(out io.Writer, opts AsyncOptions) *AsyncWriter {
+	if opts.Size < 1 {
+		opts.Size = 1
+	}
+	if opts.BatchSize < 1 {
+		opts.BatchSize = DefaultBatchSize
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = time.Second
+	}
+	a := &AsyncWriter{
+		out:     out,
+		opts:    opts,
+		ring:    make([]asyncEntry, opts.Size),
+		dropped: make(map[logrus.Level]uint64),
+		wake:    make(chan struct{}, 1),
+		flushes: make(chan chan struct{}),
+		quit:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	a.notFull = sync.NewCond(&a.mu)
+	go a.run()
+	return a
+}
+
+// Write writes p at the info level, see WriteLevel
+func (a *AsyncWriter) Write(p []byte) (int, error) {
+	return a.WriteLevel(logrus.InfoLevel, p)
+}
+
+// WriteLevel queues a copy of p, the policy applies when the buffer is full.
+// p is written synchronously after Close.
+func (a *AsyncWriter) WriteLevel(level logrus.Level, p []byte) (int, error) {
+	b := make([]byte, len(p))
+	copy(b, p)
+	a.mu.Lock()
+	for a.count == len(a.ring) && !a.closed {
+		if a.opts.Policy == FullDropNewest || (a.opts.Policy == FullDropLevel && level >= a.opts.DropLevel) {
+			a.dropped[level]++
+			a.mu.Unlock()
+			// the caller is not slowed down by a dropped entry
+			return len(p), nil
+		}
+		a.notFull.Wait()
+	}
+	if a.closed {
+		a.mu.Unlock()
+		return a.out.Write(b)
+	}
+	a.ring[(a.head+a.count)%len(a.ring)] = asyncEntry{level: level, p: b}
+	a.count++
+	a.mu.Unlock()
+	select {
+	case a.wake <- struct{}{}:
+	default:
+	}
+	return len(p), nil
+}
+
+// take removes up to n entries from the ring
+func (a *AsyncWriter) take(n int) []asyncEntry {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if n > a.count {
+		n = a.count
+	}
+	batch := make([]asyncEntry, n)
+	for i := range batch {
+		batch[i] = a.ring[a.head]
+		a.ring[a.head] = asyncEntry{}
+		a.head = (a.head + 1) % len(a.ring)
+	}
+	a.count -= n
+	if n > 0 {
+		a.notFull.Broadcast()
+	}
+	return batch
+}
+
+func (a *AsyncWriter) run() {
+	defer close(a.done)
+	// pending holds whole entries, out never receives a part of an entry,
+	// so a File only rotates between two entries
+	pending := make([]byte, 0, asyncBatchBytes)
+	tk := time.NewTicker(a.opts.FlushInterval)
+	defer tk.Stop()
+	flush := func() {
+		if len(pending) == 0 {
+			return
+		}
+		if _, err := a.out.Write(pending); err != nil {
+			logWriteError(err)
+		}
+		pending = pending[:0]
+	}
+	drain := func() {
+		for {
+			batch := a.take(a.opts.BatchSize)
+			if len(batch) == 0 {
+				return
+			}
+			for _, e := range batch {
+				if len(pending) > 0 && len(pending)+len(e.p) > asyncBatchBytes {
+					flush()
+				}
+				pending = append(pending, e.p...)
+			}
+			atomic.AddUint64(&a.written, uint64(len(batch)))
+		}
+	}
+	for {
+		select {
+		case <-a.wake:
+			drain()
+		case <-tk.C:
+			drain()
+			flush()
+		case ch := <-a.flushes:
+			drain()
+			flush()
+			close(ch)
+		case <-a.quit:
+			drain()
+			flush()
+			return
+		}
+	}
+}
+
+func logWriteError(err error) {
+	log.SetFlags(log.LstdFlags)
+	log.Printf("failed to write the logs err:%s\n", err.Error())
+}
+
+// Flush writes the buffered entries and waits until they are written to out
+func (a *AsyncWriter) Flush() error {
+	ch := make(chan struct{})
+	select {
+	case a.flushes <- ch:
+		<-ch
+	case <-a.done:
+	}
+	return nil
+}
+
+// Close writes the buffered entries and stops the writer, the later writes are synchronous
+func (a *AsyncWriter) Close() error {
+	a.mu.Lock()
+	if !a.closed {
+		a.closed = true
+		close(a.quit)
+		a.notFull.Broadcast()
+	}
+	a.mu.Unlock()
+	<-a.done
+	return nil
+}
+
+// Stats returns a snapshot of the counters
+func (a *AsyncWriter) Stats() AsyncStats {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	s := AsyncStats{
+		Buffered: a.count,
+		Written:  atomic.LoadUint64(&a.written),
+		Dropped:  make(map[string]uint64, len(a.dropped)),
+	}
+	for l, n := range a.dropped {
+		s.Dropped[l.String()] = n
+	}
+	return s
+}
+
+// levelMark starts the bytes formatted by a levelFormatter, it is followed by the level of the entry.
+// logrus formats an entry outside of its lock, the level is carried with the bytes to the File.
+const levelMark = "\x00gof-level:"
+
+// levelFormatter marks the bytes it formats with the level of the entry, File.Write removes the mark
+type levelFormatter struct {
+	logrus.Formatter
+}
+
+// Format implements logrus.Formatter
+func (f *levelFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	b, err := f.Formatter.Format(entry)
+	if err != nil {
+		return nil, err
+	}
+	p := make([]byte, 0, len(levelMark)+1+len(b))
+	p = append(append(append(p, levelMark...), byte(entry.Level)), b...)
+	return p, nil
+}
+
+// splitLevel returns the level marked by a levelFormatter and the bytes without the mark,
+// unmarked bytes are at the info level
+func splitLevel(p []byte) (logrus.Level, []byte) {
+	if len(p) > len(levelMark) && string(p[:len(levelMark)]) == levelMark {
+		return logrus.Level(p[len(levelMark)]), p[len(levelMark)+1:]
+	}
+	return logrus.InfoLevel, p
+}
+
+// SetFormatter replaces the formatter of the logger of the file,
+// the levels are kept for the drop_level policy
+func (fl *File) SetFormatter(formatter logrus.Formatter) {
+	fl.Logger.Formatter = &levelFormatter{Formatter: formatter}
+}
+
+// asyncWriter returns the async writer when gofconf.Log.Async is enabled, it is created
+// on the first write and closed on the first write after Async is disabled
+func (fl *File) asyncWriter() *AsyncWriter {
+	enabled := gofconf.DefaultLog.Async
+	a := fl.async.Load().(*AsyncWriter)
+	if enabled == (a != nil) {
+		return a
+	}
+	fl.asyncMu.Lock()
+	defer fl.asyncMu.Unlock()
+	a = fl.async.Load().(*AsyncWriter)
+	switch {
+	case enabled && a == nil && !fl.noAsync:
+		a = NewAsyncWriter(writerFunc(fl.write), ConfigAsyncOptions())
+		fl.async.Store(a)
+	case !enabled && a != nil:
+		a.Close()
+		a = nil
+		fl.async.Store(a)
+	}
+	return a
+}
+
+func (fl *File) closeAsync() {
+	fl.asyncMu.Lock()
+	defer fl.asyncMu.Unlock()
+	if a := fl.async.Load().(*AsyncWriter); a != nil {
+		a.Close()
+		fl.async.Store((*AsyncWriter)(nil))
+	}
+}
+
+// Flush writes the entries buffered by the async writer
+func (fl *File) Flush() error {
+	if a := fl.async.Load().(*AsyncWriter); a != nil {
+		return a.Flush()
+	}
+	return nil
+}
+
+// AsyncStats returns the counters of the async writer, they are empty when the writes are synchronous
+func (fl *File) AsyncStats() AsyncStats {
+	if a := fl.async.Load().(*AsyncWriter); a != nil {
+		return a.Stats()
+	}
+	return AsyncStats{}
+}
+
+// Flush writes the entries buffered by the async writers of every file
+func Flush() error {
+	fileMu.Lock()
+	files := make([]*File, 0, len(fileMap))
+	for _, fb := range fileMap {
+		files = append(files, fb)
+	}
+	fileMu.Unlock()
+	var errs error
+	for _, fb := range files {
+		errs = errors.Append(errs, fb.Flush())
+	}
+	return errs
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) {
+	return f(p)
+}
@@ -0,0 +1,138 @@
+/*******************************************************************************
+ * Copyright (c) 2018  charles
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NON INFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ * -------------------------------------------------------------------------
+ * created at 2026-11-05 11:20:35
+ ******************************************************************************/
+
+package goflogger
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/atcharles/gof/gofconf"
+	"github.com/sirupsen/logrus"
+)
+
+func TestAsyncRotateKeepsEntries(t *testing.T) {
+	gofconf.DefaultLog.Async = true
+	gofconf.DefaultLog.AsyncBuffer = 1024
+	gofconf.DefaultLog.FullPolicy = FullBlock
+	defer func() {
+		gofconf.DefaultLog.Async = false
+	}()
+	fl := newTestFile(t, Policy{MaxSize: 64 * KB})
+	writeLines(fl, 8, 5000)
+	if err := fl.Close(); err != nil {
+		t.Fatal(err)
+	}
+	backups := fl.Backups()
+	if len(backups) < 10 {
+		t.Fatalf("got %d rotated files, want many", len(backups))
+	}
+	checkLines(t, readLines(t, append(backups, fl.name)...), 8, 5000)
+}
+
+// lockedBuffer records the writes, the test holds mu to block the writer
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func TestAsyncDropLevel(t *testing.T) {
+	out := new(lockedBuffer)
+	// the writer blocks on out from its second entry, the buffer is full afterwards
+	out.mu.Lock()
+	a := NewAsyncWriter(out, AsyncOptions{Size: 4, Policy: FullDropLevel, DropLevel: logrus.InfoLevel})
+	entry := append(bytes.Repeat([]byte("i"), 40*1024), '\n')
+	for i := 0; i < 20; i++ {
+		a.WriteLevel(logrus.InfoLevel, entry)
+	}
+	dropped := a.Stats().Dropped[logrus.InfoLevel.String()]
+	if dropped == 0 {
+		t.Fatal("no info entry was dropped")
+	}
+	out.mu.Unlock()
+	a.WriteLevel(logrus.ErrorLevel, []byte("error\n"))
+	if err := a.Close(); err != nil {
+		t.Fatal(err)
+	}
+	s := a.Stats()
+	if s.Written+dropped != 21 || s.Dropped[logrus.ErrorLevel.String()] != 0 {
+		t.Fatalf("unexpected stats %+v", s)
+	}
+	if !bytes.HasSuffix(out.buf.Bytes(), []byte("error\n")) {
+		t.Fatalf("the error entry was not written: %q", out.buf.String())
+	}
+}
+
+func TestAsyncDropLevelConcurrent(t *testing.T) {
+	gofconf.DefaultLog.Async = true
+	gofconf.DefaultLog.AsyncBuffer = 4
+	gofconf.DefaultLog.FullPolicy = FullDropLevel
+	gofconf.DefaultLog.DropLevel = "info"
+	defer func() {
+		gofconf.DefaultLog.Async = false
+	}()
+	fl := newTestFile(t, Policy{})
+	const goroutines, count = 8, 2000
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < count; i++ {
+				fl.Logger.Infof("g=%d i=%d", g, i)
+				fl.Logger.Warnf("g=%d i=%d", g, i)
+			}
+		}(g)
+	}
+	wg.Wait()
+	stats := fl.AsyncStats()
+	if err := fl.Close(); err != nil {
+		t.Fatal(err)
+	}
+	for level := range stats.Dropped {
+		if level != logrus.InfoLevel.String() {
+			t.Fatalf("dropped entries at the %s level: %+v", level, stats)
+		}
+	}
+	warnings := 0
+	for _, line := range readLines(t, fl.name) {
+		if strings.HasPrefix(line, "\x00") {
+			t.Fatalf("the level mark was written: %q", line)
+		}
+		if strings.Contains(line, "level=warning") {
+			warnings++
+		}
+	}
+	if warnings != goroutines*count {
+		t.Fatalf("got %d warnings, want %d", warnings, goroutines*count)
+	}
+}
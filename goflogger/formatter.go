@@ -0,0 +1,183 @@
+/*******************************************************************************
+ * Copyright (c) 2018  charles
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NON INFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ * -------------------------------------------------------------------------
+ * created at 2026-10-31 10:40:12
+ ******************************************************************************/
+
+package goflogger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/atcharles/gof/gofconf"
+	"github.com/atcharles/gof/gofutils"
+	"github.com/sirupsen/logrus"
+)
+
+// FieldNames are the keys of the fields written by JSONFormatter, an empty name omits the field
+type FieldNames struct {
+	Time     string
+	Level    string
+	Message  string
+	Caller   string
+	Service  string
+	Version  string
+	Hostname string
+	IP       string
+}
+
+// DefaultFieldNames ...
+var DefaultFieldNames = FieldNames{
+	Time:     "time",
+	Level:    "level",
+	Message:  "msg",
+	Caller:   "caller",
+	Service:  "service",
+	Version:  "version",
+	Hostname: "hostname",
+	IP:       "ip",
+}
+
+// JSONFormatter writes one JSON object per line, with the static fields of the service
+type JSONFormatter struct {
+	FieldNames FieldNames
+	//TimestampFormat defaults to time.RFC3339Nano
+	TimestampFormat string
+	//DisableCaller omits the file:line of the log call
+	DisableCaller bool
+	//Static fields added to every entry, the fields of the entry take precedence
+	Static logrus.Fields
+}
+
+// NewJSONFormatter returns a JSONFormatter with the service name of gofconf.DefaultLog,
+// gofconf.Version, the hostname and the intranet IP
+func NewJSONFormatter() *JSONFormatter {
+	f := &JSONFormatter{FieldNames: DefaultFieldNames, Static: logrus.Fields{}}
+	service := gofconf.DefaultLog.Service
+	if service == "" {
+		service = filepath.Base(gofutils.SelfPath())
+	}
+	f.setStatic(f.FieldNames.Service, service)
+	f.setStatic(f.FieldNames.Version, gofconf.Version)
+	if host, err := os.Hostname(); err == nil {
+		f.setStatic(f.FieldNames.Hostname, host)
+	}
+	if ip, err := gofutils.IntranetIP(); err == nil {
+		f.setStatic(f.FieldNames.IP, ip)
+	}
+	return f
+}
+
+func (f *JSONFormatter) setStatic(key string, value interface{}) {
+	if key != "" {
+		f.Static[key] = value
+	}
+}
+
+// Format implements logrus.Formatter
+func (f *JSONFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	names := f.FieldNames
+	data := make(logrus.Fields, len(f.Static)+len(entry.Data)+4)
+	for k, v := range f.Static {
+		data[k] = v
+	}
+	for k, v := range entry.Data {
+		if err, ok := v.(error); ok {
+			// errors are marshaled as {} by encoding/json
+			v = err.Error()
+		}
+		data[k] = v
+	}
+	layout := f.TimestampFormat
+	if layout == "" {
+		layout = time.RFC3339Nano
+	}
+	if names.Time != "" {
+		data[names.Time] = entry.Time.Format(layout)
+	}
+	if names.Level != "" {
+		data[names.Level] = entry.Level.String()
+	}
+	if names.Message != "" {
+		data[names.Message] = entry.Message
+	}
+	if names.Caller != "" && !f.DisableCaller {
+		if caller := callerOf(); caller != "" {
+			data[names.Caller] = caller
+		}
+	}
+	buf := new(bytes.Buffer)
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(data); err != nil {
+		return nil, fmt.Errorf("failed to marshal the log fields: %s", err.Error())
+	}
+	return buf.Bytes(), nil
+}
+
+// callerOf returns the file:line of the first frame outside of logrus and goflogger
+func callerOf() string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !strings.Contains(frame.Function, "github.com/sirupsen/logrus") &&
+			!strings.Contains(frame.Function, "/goflogger.") {
+			return filepath.Base(filepath.Dir(frame.File)) + "/" + filepath.Base(frame.File) + fmt.Sprintf(":%d", frame.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+// configFormatter follows gofconf.DefaultLog.Format, so the format of the loggers created
+// before the configuration is read changes with it
+type configFormatter struct {
+	text logrus.Formatter
+	// json is created once by the first json entry, with the service name read from the configuration
+	json     *JSONFormatter
+	jsonOnce sync.Once
+}
+
+func newConfigFormatter() *configFormatter {
+	return &configFormatter{text: new(logrus.TextFormatter)}
+}
+
+// Format implements logrus.Formatter
+func (f *configFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	if strings.ToLower(gofconf.DefaultLog.Format) != "json" {
+		return f.text.Format(entry)
+	}
+	f.jsonOnce.Do(func() {
+		f.json = NewJSONFormatter()
+	})
+	return f.json.Format(entry)
+}
@@ -0,0 +1,60 @@
+/*******************************************************************************
+ * Copyright (c) 2018  charles
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NON INFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ * -------------------------------------------------------------------------
+ * created at 2026-11-07 16:48:31
+ ******************************************************************************/
+
+package goflogger
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/atcharles/gof/gofconf"
+	"github.com/sirupsen/logrus"
+)
+
+func TestConfigFormatterConcurrentJSON(t *testing.T) {
+	gofconf.DefaultLog.Format = "json"
+	defer func() {
+		gofconf.DefaultLog.Format = "text"
+	}()
+	f := newConfigFormatter()
+	logger := logrus.New()
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			b, err := f.Format(logrus.NewEntry(logger).WithField("k", "v"))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			var m map[string]interface{}
+			if err := json.Unmarshal(b, &m); err != nil || m["k"] != "v" {
+				t.Errorf("unexpected entry %s: %v", b, err)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,153 @@
+/*******************************************************************************
+ * Copyright (c) 2018  charles
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NON INFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ * -------------------------------------------------------------------------
+ * created at 2026-11-01 10:18:55
+ ******************************************************************************/
+
+package goflogger
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/atcharles/gof/gofconf"
+	"github.com/atcharles/gof/gofutils"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	levelMu sync.Mutex
+	//overrides are the levels set by SetLevel, they win over the configuration
+	overrides = make(map[string]logrus.Level)
+)
+
+func init() {
+	// the loggers created before the configuration is read get its levels at startup,
+	// then the levels follow the reloads
+	gofconf.OnLoad(ApplyLevels)
+}
+
+// Module returns the logger of a module, e.g. sql, http or cache, it writes to logs/<name>/<name>.log.
+// Its level is the level of the module in gofconf.Log.Levels, or gofconf.Log.Level, see SetLevel.
+func Module(name string) *Logger {
+	name = strings.ToLower(name)
+	fb := getFile(gofutils.SelfDir() + "logs/" + name + gofutils.Delimiter + name + ".log")
+	levelMu.Lock()
+	defer levelMu.Unlock()
+	if fb.module != name {
+		fb.module = name
+		fb.Logger.SetLevel(levelOf(name))
+	}
+	return fb.Logger
+}
+
+// configLevel returns the configured level of a module, the modules without a level use Level
+func configLevel(module string) logrus.Level {
+	c := gofconf.DefaultLog
+	if level, ok := c.Levels[module]; ok && module != "" {
+		if l, err := logrus.ParseLevel(level); err == nil {
+			return l
+		}
+	}
+	if l, err := logrus.ParseLevel(c.Level); err == nil {
+		return l
+	}
+	return logrus.InfoLevel
+}
+
+// levelOf is called with levelMu held
+func levelOf(module string) logrus.Level {
+	if l, ok := overrides[module]; ok && module != "" {
+		return l
+	}
+	return configLevel(module)
+}
+
+// ApplyLevels sets the level of every logger from the configuration and the overrides,
+// it is called when the configuration is loaded and on every reload
+func ApplyLevels() {
+	fileMu.Lock()
+	files := make([]*File, 0, len(fileMap))
+	for _, fb := range fileMap {
+		files = append(files, fb)
+	}
+	fileMu.Unlock()
+	levelMu.Lock()
+	defer levelMu.Unlock()
+	for _, fb := range files {
+		fb.Logger.SetLevel(levelOf(fb.module))
+	}
+}
+
+// SetLevel changes the level of a module until ResetLevel, the configuration reloads do not change it
+func SetLevel(module, level string) error {
+	l, err := logrus.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	levelMu.Lock()
+	overrides[strings.ToLower(module)] = l
+	levelMu.Unlock()
+	ApplyLevels()
+	return nil
+}
+
+// ResetLevel gives a module the configured level back
+func ResetLevel(module string) {
+	levelMu.Lock()
+	delete(overrides, strings.ToLower(module))
+	levelMu.Unlock()
+	ApplyLevels()
+}
+
+// ModuleLevel is the level of a module
+type ModuleLevel struct {
+	Module string `json:"module"`
+	Level  string `json:"level"`
+	//Override is true when the level is set by SetLevel
+	Override bool `json:"override"`
+}
+
+// Levels returns the levels of the modules, sorted by name
+func Levels() []ModuleLevel {
+	fileMu.Lock()
+	files := make([]*File, 0, len(fileMap))
+	for _, fb := range fileMap {
+		files = append(files, fb)
+	}
+	fileMu.Unlock()
+	levelMu.Lock()
+	defer levelMu.Unlock()
+	modules := make([]string, 0)
+	for _, fb := range files {
+		if fb.module != "" {
+			modules = append(modules, fb.module)
+		}
+	}
+	sort.Strings(modules)
+	list := make([]ModuleLevel, len(modules))
+	for i, m := range modules {
+		_, ok := overrides[m]
+		list[i] = ModuleLevel{Module: m, Level: levelOf(m).String(), Override: ok}
+	}
+	return list
+}
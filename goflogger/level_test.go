@@ -0,0 +1,68 @@
+/*******************************************************************************
+ * Copyright (c) 2018  charles
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NON INFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ * -------------------------------------------------------------------------
+ * created at 2026-11-07 10:12:44
+ ******************************************************************************/
+
+package goflogger
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/atcharles/gof/gofconf"
+	"github.com/sirupsen/logrus"
+)
+
+// startConfig starts gofconf with content as the global file
+func startConfig(t *testing.T, content string) {
+	t.Helper()
+	dir := t.TempDir()
+	gofconf.SetConfigDir(dir)
+	gofconf.SetHistoryDir(filepath.Join(dir, ".history"))
+	if err := ioutil.WriteFile(gofconf.ConfigFile(), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := gofconf.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		gofconf.Stop(context.Background())
+		gofconf.DefaultLog.Levels = nil
+		ApplyLevels()
+	})
+}
+
+func TestModuleLevelBeforeStart(t *testing.T) {
+	early := Module("early")
+	if early.Level != logrus.InfoLevel {
+		t.Fatalf("level before start %s, want info", early.Level)
+	}
+	startConfig(t, "log:\n  consoleenable: false\n  levels:\n    early: debug\n")
+	if early.Level != logrus.DebugLevel {
+		t.Fatalf("level after start %s, want debug", early.Level)
+	}
+	if l := Module("late").Level; l != logrus.InfoLevel {
+		t.Fatalf("level of a module without a configured level %s, want info", l)
+	}
+}
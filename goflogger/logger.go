@@ -25,14 +25,16 @@
 package goflogger
 
 import (
-	"fmt"
+	"context"
 	"log"
 	"os"
 	"path/filepath"
 	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/atcharles/gof/gofutils"
+	"github.com/atcharles/gof/gofconf"
+	"github.com/atcharles/gof/gofutils/errors"
 	"github.com/robfig/cron"
 	"github.com/sirupsen/logrus"
 )
@@ -56,21 +58,21 @@ const (
 var (
 	//fileMap 全局文件 map
 	fileMap = make(map[string]*File)
-	//n 秒 处理一次 文件
-	freshTime = 1 * time.Second
-	//文件 备份 最大文件大小
-	maxFile = 1 * MB
-	//保留 n 天
-	dirDays = 3
+	fileMu  sync.Mutex
 )
 
 var (
+	//Cron Deprecated: it runs every job on every instance, use gofcron instead.
 	Cron *cron.Cron
+	//cleanCron removes the rotated files out of the policies, it is not shared with the application
+	cleanCron *cron.Cron
 )
 
 func init() {
 	Cron = cron.New()
 	Cron.Start()
+	cleanCron = cron.New()
+	cleanCron.Start()
 }
 
 //Logger ...
@@ -78,11 +80,36 @@ type Logger struct {
 	*logrus.Logger
 }
 
-//File ...
+//File is a log file rotated by its Policy, see SetPolicy.
+//It is an io.Writer, the writes and the rotations are serialized.
+//The writes go through an AsyncWriter when gofconf.Log.Async is enabled.
 type File struct {
 	Logger *Logger
 	f      *os.File
-	mu     sync.RWMutex
+	name   string
+	size   int64
+	//since is the time of the first line of the file
+	since  time.Time
+	custom *Policy
+	closed bool
+	//seq is the number of the next backup in seqDir
+	seq    int
+	seqDir string
+	//module is set by Module, it is read with levelMu held
+	module string
+	//console is 1 once SetConsole enabled the console sink of the logger
+	console uint32
+	//async holds the *AsyncWriter of the file, nil when the writes are synchronous
+	async   atomic.Value
+	asyncMu sync.Mutex
+	noAsync bool
+
+	mu   sync.RWMutex
+	quit chan struct{}
+	once sync.Once
+	//bg waits for the compressions
+	bg      sync.WaitGroup
+	pruneMu sync.Mutex
 }
 
 //GetFile ...
@@ -92,121 +119,150 @@ func GetFile(fName string) *File {
 
 //getFile ... 获取一个初始化文件指针,文件名可以带路径,如果文件不存在,则创建
 func getFile(fName string) *File {
+	fileMu.Lock()
+	defer fileMu.Unlock()
 	if fileMap[fName] == nil {
-		fb := &File{mu: sync.RWMutex{}}
+		fb := &File{mu: sync.RWMutex{}, quit: make(chan struct{})}
+		fb.async.Store((*AsyncWriter)(nil))
 		if err := fb.innerFile(fName); err != nil {
 			panic(err)
 		}
 		l := &logrus.Logger{
-			Out:       fb.f,
-			Formatter: new(logrus.TextFormatter),
+			Out:       fb,
+			Formatter: &levelFormatter{Formatter: newConfigFormatter()},
 			Hooks:     make(logrus.LevelHooks),
-			Level:     logrus.DebugLevel,
+			Level:     configLevel(""),
 		}
 		fb.Logger = &Logger{l}
+		addSinks(fb)
 		go fb.backPack()
+		if fb.policy().External {
+			reopenOnSIGHUP()
+		}
 		//每日凌晨1点执行
 		//0 0 1 * * *
-		Cron.AddFunc("0 0 1 * * *", func() {
-			fb.delDirForDays()
+		cleanCron.AddFunc("0 0 1 * * *", func() {
+			fb.prune(fb.policy())
 		})
 		fileMap[fName] = fb
 	}
 	return fileMap[fName]
 }
 
-func (fl *File) delDirForDays() {
-	dirSlice := make([]string, 0)    //不被删除的目录
-	dir := filepath.Dir(fl.f.Name()) //当前文件目录
-	for i := 0; i < dirDays; i++ {
-		dStr := time.Now().Local().AddDate(0, 0, i*-1).Format("20060102")
-		dirStr := fmt.Sprintf("%s%s%s", dir, gofutils.Delimiter, dStr)
-		dirSlice = append(dirSlice, dirStr)
+//Write writes to the current file, or queues p when the writes are asynchronous.
+//Nothing is written when gofconf.Log.FileEnable is off.
+//The level marked by the formatter of the logger is removed from p and used like WriteLevel.
+func (fl *File) Write(p []byte) (int, error) {
+	level, b := splitLevel(p)
+	if _, err := fl.WriteLevel(level, b); err != nil {
+		return 0, err
 	}
+	return len(p), nil
+}
 
-	//当前文件目录下的所有目录
-	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		if !info.IsDir() {
-			return nil
-		}
-		if path == dir {
-			return nil
-		}
-		for _, v := range dirSlice {
-			if v == path {
-				return nil
-			}
-		}
-		return os.RemoveAll(path)
-	})
+//WriteLevel writes p like Write, the level is used by the drop_level policy, see LevelWriter
+func (fl *File) WriteLevel(level logrus.Level, p []byte) (int, error) {
+	if !gofconf.DefaultLog.FileEnable {
+		return len(p), nil
+	}
+	if a := fl.asyncWriter(); a != nil {
+		return a.WriteLevel(level, p)
+	}
+	return fl.write(p)
 }
 
-func (fl *File) packAction() error {
+//write writes to the current file, the file is rotated first when p does not fit in it
+func (fl *File) write(p []byte) (int, error) {
+	fl.mu.Lock()
+	defer fl.mu.Unlock()
+	if fl.closed {
+		return 0, os.ErrClosed
+	}
+	if err := fl.rotateIfNeeded(int64(len(p))); err != nil {
+		log.SetFlags(log.LstdFlags)
+		log.Printf("failed to rotate %s err:%s\n", fl.name, err.Error())
+	}
+	n, err := fl.f.Write(p)
+	fl.size += int64(n)
+	return n, err
+}
 
-	//获取文件大小
-	size, err := gofutils.GetFileSize(fl.f)
-	if err != nil {
+//rotateIfNeeded is called with mu held
+func (fl *File) rotateIfNeeded(incoming int64) error {
+	pol := fl.policyLocked()
+	if pol.External || !fl.needRotate(pol, fl.size, incoming, time.Now()) {
+		return nil
+	}
+	return fl.rotate(pol)
+}
+
+//rotate moves the file to its backup name and opens a new one, it is called with mu held
+func (fl *File) rotate(pol Policy) error {
+	fullName := fl.backupName()
+	if err := os.MkdirAll(filepath.Dir(fullName), 0755); err != nil {
 		return err
 	}
-	if size >= int64(maxFile) {
-		//文件加锁
-		fl.mu.Lock()
-		defer fl.mu.Unlock()
-		//获取文件当日目录
-		dir := gofutils.TodayDir(fl.f)
-		//获取文件名
-		stat, err := fl.f.Stat()
-		if err != nil {
-			return err
-		}
-		var fullName string
-		name := stat.Name()
-		n := 0
-		for {
-			fullName = fmt.Sprintf("%s%s%s.%06d.log", dir, gofutils.Delimiter, name, n)
-			if fl.fileExist(fullName) {
-				n++
-			} else {
-				break
-			}
-		}
-		if err := os.MkdirAll(filepath.Dir(fullName), 0755); err != nil {
-			return err
-		}
-		_, err = gofutils.CopyFile(fullName, fl.f.Name())
-		if err != nil {
-			return err
-		}
-		if err := os.Truncate(fl.f.Name(), 0); err != nil {
-			return err
-		}
-		fl.f.Sync()
+	if err := os.Rename(fl.name, fullName); err != nil {
+		return err
+	}
+	// the old descriptor still points at the renamed file, nothing is written to it after the swap
+	old := fl.f
+	if err := fl.open(); err != nil {
+		return err
 	}
+	old.Close()
+	fl.size = 0
+	fl.since = time.Now()
+	fl.afterRotate(pol, fullName)
 	return nil
 }
 
+func (fl *File) packAction() error {
+	fl.mu.Lock()
+	defer fl.mu.Unlock()
+	if fl.closed {
+		return nil
+	}
+	return fl.rotateIfNeeded(0)
+}
+
+//backPack rotates the idle files at the start of a period
 func (fl *File) backPack() {
-	tk := time.NewTicker(freshTime)
-	defer func() {
-		tk.Stop()
-		fl.f.Close()
-	}()
+	tk := time.NewTicker(checkInterval)
+	defer tk.Stop()
 	for {
 		select {
+		case <-fl.quit:
+			return
 		case <-tk.C:
 			if err := fl.packAction(); err != nil {
-				log.Printf("err:%s\n", err.Error())
-				return
+				log.SetFlags(log.LstdFlags)
+				log.Printf("failed to rotate %s err:%s\n", fl.name, err.Error())
 			}
 		}
 	}
 }
 
-//GetFile ...
+//Reopen closes and reopens the file, the files moved by an external tool like logrotate
+//are released, see Policy.External
+func (fl *File) Reopen() error {
+	fl.mu.Lock()
+	defer fl.mu.Unlock()
+	if fl.closed {
+		return nil
+	}
+	old := fl.f
+	if err := fl.open(); err != nil {
+		return err
+	}
+	return old.Close()
+}
+
+//GetFile returns the current file.
+//Deprecated: the file is closed when it is rotated, write to the File itself.
 func (fl *File) GetFile() *os.File {
+	fl.mu.RLock()
+	defer fl.mu.RUnlock()
 	return fl.f
 }
 
@@ -223,14 +279,37 @@ func (fl *File) fileExist(fName string) bool {
 }
 
 func (fl *File) innerFile(fName string) error {
-	if !fl.fileExist(fName) {
-		os.MkdirAll(filepath.Dir(fName), 0755)
+	fl.name = fName
+	if err := fl.open(); err != nil {
+		return err
+	}
+	fl.since = time.Now()
+	// the last write of a file left by the previous run is older than its first line,
+	// it is enough to rotate the file in the next period
+	if fl.size > 0 {
+		if stat, err := fl.f.Stat(); err == nil {
+			fl.since = stat.ModTime()
+		}
 	}
-	file, err := os.OpenFile(fName, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0666)
+	return nil
+}
+
+//open opens the file name for appending and reads its size
+func (fl *File) open() error {
+	if !fl.fileExist(fl.name) {
+		os.MkdirAll(filepath.Dir(fl.name), 0755)
+	}
+	file, err := os.OpenFile(fl.name, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0666)
+	if err != nil {
+		return err
+	}
+	stat, err := file.Stat()
 	if err != nil {
+		file.Close()
 		return err
 	}
 	fl.f = file
+	fl.size = stat.Size()
 	return nil
 }
 
@@ -238,5 +317,43 @@ func (fl *File) innerFile(fName string) error {
 func (fl *File) Reload() {
 	fl.mu.Lock()
 	defer fl.mu.Unlock()
-	os.Truncate(fl.f.Name(), 0)
+	if os.Truncate(fl.name, 0) == nil {
+		fl.size = 0
+	}
+}
+
+//Close stops the backup of the file, writes the buffered entries,
+//waits for the compressions, flushes and closes it
+func (fl *File) Close() error {
+	fl.once.Do(func() {
+		close(fl.quit)
+	})
+	fl.asyncMu.Lock()
+	fl.noAsync = true
+	fl.asyncMu.Unlock()
+	fl.closeAsync()
+	fl.bg.Wait()
+	fl.mu.Lock()
+	defer fl.mu.Unlock()
+	if fl.closed {
+		return nil
+	}
+	fl.closed = true
+	if err := fl.f.Sync(); err != nil {
+		return err
+	}
+	return fl.f.Close()
+}
+
+//Close stops Cron and closes every log file, call it when the program exits
+func Close(ctx context.Context) error {
+	Cron.Stop()
+	cleanCron.Stop()
+	errs := closeSinks()
+	fileMu.Lock()
+	defer fileMu.Unlock()
+	for _, fb := range fileMap {
+		errs = errors.Append(errs, fb.Close())
+	}
+	return errs
 }
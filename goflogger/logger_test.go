@@ -0,0 +1,200 @@
+/*******************************************************************************
+ * Copyright (c) 2018  charles
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NON INFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ * -------------------------------------------------------------------------
+ * created at 2026-11-05 10:12:40
+ ******************************************************************************/
+
+package goflogger
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"testing"
+
+	"github.com/atcharles/gof/gofconf"
+)
+
+var lineRe = regexp.MustCompile(`^time="[^"]+" level=info msg="g=(\d+) i=(\d+)"$`)
+
+func init() {
+	gofconf.DefaultLog.ConsoleEnable = false
+}
+
+// writeLines writes goroutines*lines entries concurrently
+func writeLines(fl *File, goroutines, lines int) {
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < lines; i++ {
+				fl.Logger.Infof("g=%d i=%d", g, i)
+			}
+		}(g)
+	}
+	wg.Wait()
+}
+
+// readLines returns the lines of the files, the .gz files are decompressed
+func readLines(t *testing.T, names ...string) []string {
+	t.Helper()
+	lines := make([]string, 0)
+	for _, name := range names {
+		f, err := os.Open(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var r io.Reader = f
+		if filepath.Ext(name) == ".gz" {
+			zr, err := gzip.NewReader(f)
+			if err != nil {
+				f.Close()
+				t.Fatalf("%s: %s", name, err)
+			}
+			r = zr
+		}
+		sc := bufio.NewScanner(r)
+		for sc.Scan() {
+			lines = append(lines, sc.Text())
+		}
+		f.Close()
+		if err := sc.Err(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return lines
+}
+
+// checkLines fails on a torn, missing or duplicated line
+func checkLines(t *testing.T, lines []string, goroutines, count int) {
+	t.Helper()
+	seen := make(map[string]bool, len(lines))
+	for _, line := range lines {
+		m := lineRe.FindStringSubmatch(line)
+		if m == nil {
+			t.Fatalf("torn line: %q", line)
+		}
+		key := m[1] + " " + m[2]
+		if seen[key] {
+			t.Fatalf("duplicated line: %q", line)
+		}
+		seen[key] = true
+	}
+	if len(seen) != goroutines*count {
+		t.Fatalf("got %d lines, want %d", len(seen), goroutines*count)
+	}
+}
+
+func newTestFile(t *testing.T, p Policy) *File {
+	t.Helper()
+	fl := GetFile(filepath.Join(t.TempDir(), "test.log"))
+	fl.SetPolicy(p)
+	t.Cleanup(func() {
+		fl.Close()
+	})
+	return fl
+}
+
+func TestRotateConcurrentWrites(t *testing.T) {
+	for _, compress := range []bool{false, true} {
+		t.Run(fmt.Sprintf("compress=%v", compress), func(t *testing.T) {
+			fl := newTestFile(t, Policy{MaxSize: 4 * KB, Compress: compress})
+			writeLines(fl, 8, 2000)
+			if err := fl.Close(); err != nil {
+				t.Fatal(err)
+			}
+			backups := fl.Backups()
+			if len(backups) < 10 {
+				t.Fatalf("got %d rotated files, want many", len(backups))
+			}
+			for _, b := range backups {
+				if compress != (filepath.Ext(b) == ".gz") {
+					t.Fatalf("unexpected rotated file %s", b)
+				}
+				if st, err := os.Stat(b); err == nil && !compress && st.Size() > int64(4*KB) {
+					t.Fatalf("%s exceeds the max size: %d", b, st.Size())
+				}
+			}
+			checkLines(t, readLines(t, append(backups, fl.name)...), 8, 2000)
+		})
+	}
+}
+
+func TestRotateMaxBackups(t *testing.T) {
+	fl := newTestFile(t, Policy{MaxSize: KB, MaxBackups: 3})
+	writeLines(fl, 1, 500)
+	if err := fl.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(fl.Backups()); n != 3 {
+		t.Fatalf("got %d rotated files, want 3", n)
+	}
+}
+
+func TestReopenConcurrentWrites(t *testing.T) {
+	fl := newTestFile(t, Policy{External: true})
+	moved := make([]string, 0)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		writeLines(fl, 8, 2000)
+	}()
+	// what logrotate does: rename the file, then ask for a reopen
+	for i := 0; ; i++ {
+		select {
+		case <-done:
+		default:
+			name := fmt.Sprintf("%s.%d", fl.name, i)
+			if err := os.Rename(fl.name, name); err != nil {
+				t.Fatal(err)
+			}
+			moved = append(moved, name)
+			if err := Reopen(); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		break
+	}
+	if err := fl.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(moved) == 0 {
+		t.Fatal("the file was never moved")
+	}
+	checkLines(t, readLines(t, append(moved, fl.name)...), 8, 2000)
+}
+
+func TestCloseFlushes(t *testing.T) {
+	fl := newTestFile(t, Policy{})
+	writeLines(fl, 2, 100)
+	if err := Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	checkLines(t, readLines(t, fl.name), 2, 100)
+}
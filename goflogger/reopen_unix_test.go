@@ -0,0 +1,93 @@
+//go:build !windows
+// +build !windows
+
+/*******************************************************************************
+ * Copyright (c) 2018  charles
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NON INFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ * -------------------------------------------------------------------------
+ * created at 2026-11-05 10:48:02
+ ******************************************************************************/
+
+package goflogger
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestReopenOnSIGHUP(t *testing.T) {
+	fl := newTestFile(t, Policy{External: true})
+	writeLines(fl, 1, 10)
+	moved := fl.name + ".1"
+	if err := os.Rename(fl.name, moved); err != nil {
+		t.Fatal(err)
+	}
+	if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatal(err)
+	}
+	// the new file is created by the reopen
+	deadline := time.Now().Add(5 * time.Second)
+	for !fl.fileExist(fl.name) {
+		if time.Now().After(deadline) {
+			t.Fatal("the file was not reopened on SIGHUP")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	fl.Logger.Info("g=0 i=10")
+	if err := fl.Close(); err != nil {
+		t.Fatal(err)
+	}
+	checkLines(t, readLines(t, moved, fl.name), 1, 11)
+}
+
+// TestSIGHUPAfterStart runs in a child process, which SIGHUP kills without the handler
+func TestSIGHUPAfterStart(t *testing.T) {
+	if os.Getenv("GOFLOGGER_HUP_CHILD") == "" {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestSIGHUPAfterStart$")
+		cmd.Env = append(os.Environ(), "GOFLOGGER_HUP_CHILD=1")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("the child process: %s\n%s", err, out)
+		}
+		return
+	}
+	// the file is created before the configuration sets the external policy
+	fl := GetFile(filepath.Join(t.TempDir(), "early.log"))
+	defer fl.Close()
+	startConfig(t, "log:\n  consoleenable: false\n  rotateexternal: true\n")
+	moved := fl.name + ".1"
+	writeLines(fl, 1, 10)
+	if err := os.Rename(fl.name, moved); err != nil {
+		t.Fatal(err)
+	}
+	if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for !fl.fileExist(fl.name) {
+		if time.Now().After(deadline) {
+			t.Fatal("the file was not reopened on SIGHUP")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
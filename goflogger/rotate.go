@@ -0,0 +1,331 @@
+/*******************************************************************************
+ * Copyright (c) 2018  charles
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NON INFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ * -------------------------------------------------------------------------
+ * created at 2026-10-31 15:12:06
+ ******************************************************************************/
+
+package goflogger
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/atcharles/gof/gofconf"
+	"github.com/atcharles/gof/gofutils/errors"
+)
+
+// rotation periods
+const (
+	PeriodHourly = "hourly"
+	PeriodDaily  = "daily"
+)
+
+// checkInterval is the interval of the rotation checks of a file
+var checkInterval = 5 * time.Second
+
+var dateDir = regexp.MustCompile(`^\d{8}$`)
+
+// Policy is the rotation policy of a File.
+// The rotated files are moved to a directory named after the day of their first line,
+// e.g. logs/web/20261031/web.log.000000.log
+type Policy struct {
+	//MaxSize rotates the file when it is exceeded, 0 disables the size rotation
+	MaxSize ByteSize
+	//Every is PeriodHourly, PeriodDaily or empty
+	Every string
+	//MaxBackups is the number of rotated files kept, 0 keeps every file
+	MaxBackups int
+	//MaxAge removes the rotated files older than it, 0 keeps them forever
+	MaxAge time.Duration
+	//MaxTotal is the disk budget of the rotated files, 0 is unlimited
+	MaxTotal ByteSize
+	//Compress gzips the rotated files in the background
+	Compress bool
+	//External leaves the rotation and the removal to an external tool like logrotate,
+	//the files are reopened on SIGHUP
+	External bool
+}
+
+// ConfigPolicy returns the policy of gofconf.DefaultLog
+func ConfigPolicy() Policy {
+	c := gofconf.DefaultLog
+	return Policy{
+		MaxSize:    ByteSize(c.RotateSize) * MB,
+		Every:      strings.ToLower(c.RotateEvery),
+		MaxBackups: c.MaxBackups,
+		MaxAge:     time.Duration(c.MaxDays) * 24 * time.Hour,
+		MaxTotal:   ByteSize(c.MaxTotal) * MB,
+		Compress:   c.Compress,
+		External:   c.RotateExternal,
+	}
+}
+
+// SetPolicy replaces the policy of gofconf.DefaultLog for this file
+func (fl *File) SetPolicy(p Policy) {
+	fl.mu.Lock()
+	fl.custom = &p
+	fl.mu.Unlock()
+	if p.External {
+		reopenOnSIGHUP()
+	}
+}
+
+func (fl *File) policy() Policy {
+	fl.mu.RLock()
+	defer fl.mu.RUnlock()
+	return fl.policyLocked()
+}
+
+func (fl *File) policyLocked() Policy {
+	if fl.custom != nil {
+		return *fl.custom
+	}
+	return ConfigPolicy()
+}
+
+// periodStart returns the start of the period of t, the zero time when there is no period
+func periodStart(every string, t time.Time) time.Time {
+	t = t.Local()
+	switch every {
+	case PeriodHourly:
+		return t.Truncate(time.Hour)
+	case PeriodDaily:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	}
+	return time.Time{}
+}
+
+// needRotate reports whether a write of incoming bytes goes to a new file
+func (fl *File) needRotate(p Policy, size, incoming int64, now time.Time) bool {
+	if size == 0 {
+		return false
+	}
+	if p.MaxSize > 0 && size+incoming > int64(p.MaxSize) {
+		return true
+	}
+	return periodStart(p.Every, fl.since) != periodStart(p.Every, now)
+}
+
+// backupName returns the next name in the dated directory of the first line of the file,
+// the numbers are not reused after the old files are removed.
+// It is called with mu held.
+func (fl *File) backupName() string {
+	dir := filepath.Join(filepath.Dir(fl.name), fl.since.Local().Format("20060102"))
+	base := filepath.Base(fl.name)
+	if dir != fl.seqDir {
+		// the listing is only trusted once, the compressions rename the files concurrently
+		fl.seqDir, fl.seq = dir, 0
+		if files, err := ioutil.ReadDir(dir); err == nil {
+			for _, info := range files {
+				var i int
+				if _, err := fmt.Sscanf(strings.TrimPrefix(info.Name(), base+"."), "%06d.log", &i); err == nil && i >= fl.seq {
+					fl.seq = i + 1
+				}
+			}
+		}
+	}
+	for {
+		name := filepath.Join(dir, fmt.Sprintf("%s.%06d.log", base, fl.seq))
+		fl.seq++
+		if !fl.fileExist(name) && !fl.fileExist(name+".gz") {
+			return name
+		}
+	}
+}
+
+// afterRotate compresses the rotated file and removes the files out of the policy in the background
+func (fl *File) afterRotate(p Policy, rotated string) {
+	fl.bg.Add(1)
+	go func() {
+		defer fl.bg.Done()
+		if p.Compress {
+			if err := gzipFile(rotated); err != nil {
+				log.SetFlags(log.LstdFlags)
+				log.Printf("failed to compress %s err:%s\n", rotated, err.Error())
+			}
+		}
+		fl.prune(p)
+	}()
+}
+
+// gzipFile replaces name by name.gz
+func gzipFile(name string) error {
+	src, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	tmp := name + ".gz.tmp"
+	dst, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(dst)
+	if _, err := io.Copy(zw, src); err != nil {
+		dst.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		dst.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, name+".gz"); err != nil {
+		return err
+	}
+	return os.Remove(name)
+}
+
+type backup struct {
+	path string
+	info os.FileInfo
+}
+
+// backups returns the rotated files of the file, the newest first
+func (fl *File) backups() []backup {
+	dir := filepath.Dir(fl.name)
+	prefix := filepath.Base(fl.name) + "."
+	dirs, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	list := make([]backup, 0)
+	for _, d := range dirs {
+		if !d.IsDir() || !dateDir.MatchString(d.Name()) {
+			continue
+		}
+		files, err := ioutil.ReadDir(filepath.Join(dir, d.Name()))
+		if err != nil {
+			continue
+		}
+		for _, info := range files {
+			name := info.Name()
+			if info.IsDir() || !strings.HasPrefix(name, prefix) || strings.HasSuffix(name, ".tmp") {
+				continue
+			}
+			list = append(list, backup{path: filepath.Join(dir, d.Name(), name), info: info})
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].info.ModTime().After(list[j].info.ModTime())
+	})
+	return list
+}
+
+// prune removes the rotated files out of the policy and the empty dated directories
+func (fl *File) prune(p Policy) {
+	if p.External {
+		return
+	}
+	fl.pruneMu.Lock()
+	defer fl.pruneMu.Unlock()
+	var total int64
+	now := time.Now()
+	for i, b := range fl.backups() {
+		total += b.info.Size()
+		if (p.MaxBackups > 0 && i >= p.MaxBackups) ||
+			(p.MaxAge > 0 && now.Sub(b.info.ModTime()) > p.MaxAge) ||
+			(p.MaxTotal > 0 && total > int64(p.MaxTotal)) {
+			if err := os.Remove(b.path); err != nil && !os.IsNotExist(err) {
+				log.SetFlags(log.LstdFlags)
+				log.Printf("failed to remove %s err:%s\n", b.path, err.Error())
+			}
+		}
+	}
+	dir := filepath.Dir(fl.name)
+	dirs, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, d := range dirs {
+		if d.IsDir() && dateDir.MatchString(d.Name()) {
+			// only removes the empty directories
+			os.Remove(filepath.Join(dir, d.Name()))
+		}
+	}
+}
+
+// Backups returns the paths of the rotated files, the newest first
+func (fl *File) Backups() []string {
+	list := fl.backups()
+	paths := make([]string, len(list))
+	for i, b := range list {
+		paths[i] = b.path
+	}
+	return paths
+}
+
+var hupOnce sync.Once
+
+func init() {
+	// the files may be created before the configuration is read, and a reload may switch
+	// the policy to external: logrotate would kill the program with its SIGHUP
+	gofconf.OnLoad(func() {
+		if ConfigPolicy().External {
+			reopenOnSIGHUP()
+		}
+	})
+}
+
+// reopenOnSIGHUP reopens every file on SIGHUP, it is started once by the first external policy,
+// of a file or of the configuration
+func reopenOnSIGHUP() {
+	hupOnce.Do(func() {
+		ch := make(chan os.Signal, 1)
+		signal.Notify(ch, syscall.SIGHUP)
+		go func() {
+			for range ch {
+				if err := Reopen(); err != nil {
+					log.SetFlags(log.LstdFlags)
+					log.Printf("failed to reopen the log files err:%s\n", err.Error())
+				}
+			}
+		}()
+	})
+}
+
+// Reopen reopens every log file, e.g. in the postrotate script of logrotate
+func Reopen() error {
+	fileMu.Lock()
+	defer fileMu.Unlock()
+	var errs error
+	for _, fb := range fileMap {
+		errs = errors.Append(errs, fb.Reopen())
+	}
+	return errs
+}
@@ -0,0 +1,270 @@
+/*******************************************************************************
+ * Copyright (c) 2018  charles
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NON INFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ * -------------------------------------------------------------------------
+ * created at 2026-11-04 10:51:13
+ ******************************************************************************/
+
+package goflogger
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ShipperOptions ...
+type ShipperOptions struct {
+	// QueueSize is the number of entries waiting to be sent, the newest are dropped when it is full
+	QueueSize int
+	// BatchSize is the maximum number of entries sent at once
+	BatchSize int
+	// FlushInterval is the maximum time an entry waits for its batch
+	FlushInterval time.Duration
+	// MaxRetries of a batch, the batch is dropped afterwards, a negative value disables the retries
+	MaxRetries int
+	// Backoff is the wait before the first retry, it doubles on every retry
+	Backoff time.Duration
+	// Timeout of a request or of a write
+	Timeout time.Duration
+	// Header is added to the http requests, e.g. an Authorization header
+	Header http.Header
+}
+
+// DefaultShipperOptions ...
+var DefaultShipperOptions = ShipperOptions{
+	QueueSize:     8192,
+	BatchSize:     500,
+	FlushInterval: time.Second,
+	MaxRetries:    5,
+	Backoff:       500 * time.Millisecond,
+	Timeout:       10 * time.Second,
+}
+
+// Shipper sends the entries in batches to a log collector:
+// http(s)://... posts the batches as newline delimited JSON,
+// tcp://host:port writes the lines to a connection.
+type Shipper struct {
+	sent    uint64
+	dropped uint64
+
+	target *url.URL
+	opts   ShipperOptions
+	client *http.Client
+	conn   net.Conn
+
+	queue   chan []byte
+	quit    chan struct{}
+	done    chan struct{}
+	closeMu sync.RWMutex
+	closed  bool
+}
+
+// NewShipper starts the shipper of target, the zero options use DefaultShipperOptions, see Close
+func NewShipper(target string, opts ShipperOptions) (*Shipper, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "http", "https", "tcp":
+	default:
+		return nil, fmt.Errorf("unsupported log shipper target: %s", target)
+	}
+	def := DefaultShipperOptions
+	if opts.QueueSize < 1 {
+		opts.QueueSize = def.QueueSize
+	}
+	if opts.BatchSize < 1 {
+		opts.BatchSize = def.BatchSize
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = def.FlushInterval
+	}
+	if opts.MaxRetries == 0 {
+		opts.MaxRetries = def.MaxRetries
+	} else if opts.MaxRetries < 0 {
+		opts.MaxRetries = 0
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = def.Backoff
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = def.Timeout
+	}
+	s := &Shipper{
+		target: u,
+		opts:   opts,
+		client: &http.Client{Timeout: opts.Timeout},
+		queue:  make(chan []byte, opts.QueueSize),
+		quit:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go s.run()
+	return s, nil
+}
+
+// ShipperSink returns a sink of a Shipper, the entries are formatted as JSON
+func ShipperSink(target string, level logrus.Level, opts ShipperOptions) (*Sink, error) {
+	s, err := NewShipper(target, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Sink{Name: "shipper:" + target, Level: level, Formatter: NewJSONFormatter(), Writer: s}, nil
+}
+
+// Write queues a copy of p without waiting, p is dropped when the queue is full
+func (s *Shipper) Write(p []byte) (int, error) {
+	s.closeMu.RLock()
+	defer s.closeMu.RUnlock()
+	if s.closed {
+		atomic.AddUint64(&s.dropped, 1)
+		return len(p), nil
+	}
+	b := make([]byte, len(p))
+	copy(b, p)
+	select {
+	case s.queue <- b:
+	default:
+		atomic.AddUint64(&s.dropped, 1)
+	}
+	return len(p), nil
+}
+
+func (s *Shipper) run() {
+	defer close(s.done)
+	tk := time.NewTicker(s.opts.FlushInterval)
+	defer tk.Stop()
+	batch := make([][]byte, 0, s.opts.BatchSize)
+	flush := func() {
+		if len(batch) > 0 {
+			s.send(batch)
+			batch = batch[:0]
+		}
+	}
+	for {
+		select {
+		case b, ok := <-s.queue:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, b)
+			if len(batch) >= s.opts.BatchSize {
+				flush()
+			}
+		case <-tk.C:
+			flush()
+		}
+	}
+}
+
+// send retries the batch with a doubling backoff, the shutdown does not wait for the retries
+func (s *Shipper) send(batch [][]byte) {
+	body := bytes.Join(batch, nil)
+	wait := s.opts.Backoff
+	var err error
+	for i := 0; i <= s.opts.MaxRetries; i++ {
+		if i > 0 {
+			select {
+			case <-time.After(wait):
+			case <-s.quit:
+				i = s.opts.MaxRetries
+			}
+			wait *= 2
+		}
+		if err = s.post(body); err == nil {
+			atomic.AddUint64(&s.sent, uint64(len(batch)))
+			return
+		}
+	}
+	atomic.AddUint64(&s.dropped, uint64(len(batch)))
+	log.SetFlags(log.LstdFlags)
+	log.Printf("failed to ship %d log entries to %s err:%s\n", len(batch), s.target.Host, err.Error())
+}
+
+func (s *Shipper) post(body []byte) error {
+	if s.target.Scheme == "tcp" {
+		if s.conn == nil {
+			conn, err := net.DialTimeout("tcp", s.target.Host, s.opts.Timeout)
+			if err != nil {
+				return err
+			}
+			s.conn = conn
+		}
+		s.conn.SetWriteDeadline(time.Now().Add(s.opts.Timeout))
+		if _, err := s.conn.Write(body); err != nil {
+			// a partial batch is sent again, the collector may see a few lines twice
+			s.conn.Close()
+			s.conn = nil
+			return err
+		}
+		return nil
+	}
+	req, err := http.NewRequest(http.MethodPost, s.target.String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for k, v := range s.opts.Header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// Sent returns the number of entries sent and dropped
+func (s *Shipper) Sent() (sent, dropped uint64) {
+	return atomic.LoadUint64(&s.sent), atomic.LoadUint64(&s.dropped)
+}
+
+// Close sends the queued entries and closes the connection
+func (s *Shipper) Close() error {
+	s.closeMu.Lock()
+	if s.closed {
+		s.closeMu.Unlock()
+		<-s.done
+		return nil
+	}
+	s.closed = true
+	close(s.quit)
+	close(s.queue)
+	s.closeMu.Unlock()
+	<-s.done
+	if s.conn != nil {
+		return s.conn.Close()
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+/*******************************************************************************
+ * Copyright (c) 2018  charles
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NON INFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ * -------------------------------------------------------------------------
+ * created at 2026-11-03 14:07:44
+ ******************************************************************************/
+
+package goflogger
+
+import (
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+
+	"github.com/atcharles/gof/gofconf"
+	"github.com/atcharles/gof/gofutils/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// LevelWriter is implemented by the writers of a sink that need the level of the entry,
+// e.g. the syslog severity
+type LevelWriter interface {
+	WriteLevel(level logrus.Level, p []byte) (int, error)
+}
+
+// Sink is an output of a logger with its own level and formatter.
+// The entries are filtered by the level of the logger first, see Module.
+type Sink struct {
+	Name string
+	// Level is the most verbose level written
+	Level logrus.Level
+	// Formatter defaults to a logrus.TextFormatter
+	Formatter logrus.Formatter
+	// Writer receives the formatted entries, it must be safe for concurrent use.
+	// It is closed by Close when it is an io.Closer.
+	Writer io.Writer
+
+	enabled func() bool
+	once    sync.Once
+	err     error
+}
+
+var (
+	sinkMu sync.Mutex
+	// sinks are added to every file logger, see AddSink
+	sinks = make([]*Sink, 0)
+	// consoleOut is the writer of the console sinks of the file loggers
+	consoleOut io.Writer = os.Stdout
+)
+
+// consoleSink writes the entries of the logger of fl to stdout once fl.SetConsole is called,
+// when gofconf.Log.ConsoleEnable is set
+func consoleSink(fl *File) *Sink {
+	return &Sink{
+		Name:      "console",
+		Level:     logrus.DebugLevel,
+		Formatter: new(logrus.TextFormatter),
+		Writer:    consoleOut,
+		enabled: func() bool {
+			return gofconf.DefaultLog.ConsoleEnable && atomic.LoadUint32(&fl.console) == 1
+		},
+	}
+}
+
+// SetConsole writes the entries of the logger of the file to stdout as well, while
+// gofconf.Log.ConsoleEnable is set. The file loggers do not write to the console by default.
+func (fl *File) SetConsole(enable bool) {
+	var v uint32
+	if enable {
+		v = 1
+	}
+	atomic.StoreUint32(&fl.console, v)
+}
+
+// ConsoleSink returns a sink writing to w, e.g. os.Stderr
+func ConsoleSink(w io.Writer, level logrus.Level) *Sink {
+	return &Sink{Name: "console", Level: level, Formatter: new(logrus.TextFormatter), Writer: w}
+}
+
+// FileSink returns a sink writing to the rotating file name, see GetFile
+func FileSink(name string, level logrus.Level, formatter logrus.Formatter) *Sink {
+	return &Sink{Name: "file:" + name, Level: level, Formatter: formatter, Writer: GetFile(name)}
+}
+
+// Levels implements logrus.Hook
+func (s *Sink) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire implements logrus.Hook
+func (s *Sink) Fire(entry *logrus.Entry) error {
+	if entry.Level > s.Level || (s.enabled != nil && !s.enabled()) {
+		return nil
+	}
+	formatter := s.Formatter
+	if formatter == nil {
+		formatter = new(logrus.TextFormatter)
+	}
+	b, err := formatter.Format(entry)
+	if err != nil {
+		return err
+	}
+	if lw, ok := s.Writer.(LevelWriter); ok {
+		_, err = lw.WriteLevel(entry.Level, b)
+	} else {
+		_, err = s.Writer.Write(b)
+	}
+	return err
+}
+
+// Close closes the writer of the sink once, a File is left to Close
+func (s *Sink) Close() error {
+	s.once.Do(func() {
+		if _, ok := s.Writer.(*File); ok {
+			return
+		}
+		if c, ok := s.Writer.(io.Closer); ok {
+			s.err = c.Close()
+		}
+	})
+	return s.err
+}
+
+// AddSink adds a sink to the logger
+func (l *Logger) AddSink(s *Sink) {
+	l.AddHook(s)
+}
+
+// AddSink adds a sink to every file logger, the ones created later included
+func AddSink(s *Sink) {
+	sinkMu.Lock()
+	sinks = append(sinks, s)
+	sinkMu.Unlock()
+	fileMu.Lock()
+	defer fileMu.Unlock()
+	for _, fb := range fileMap {
+		fb.Logger.AddSink(s)
+	}
+}
+
+// addSinks is called by getFile with fileMu held
+func addSinks(fl *File) {
+	fl.Logger.AddSink(consoleSink(fl))
+	sinkMu.Lock()
+	defer sinkMu.Unlock()
+	for _, s := range sinks {
+		fl.Logger.AddSink(s)
+	}
+}
+
+// closeSinks closes the sinks added by AddSink
+func closeSinks() error {
+	sinkMu.Lock()
+	defer sinkMu.Unlock()
+	var errs error
+	for _, s := range sinks {
+		errs = errors.Append(errs, s.Close())
+	}
+	return errs
+}
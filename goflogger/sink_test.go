@@ -0,0 +1,310 @@
+/*******************************************************************************
+ * Copyright (c) 2018  charles
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NON INFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ * -------------------------------------------------------------------------
+ * created at 2026-11-06 09:41:12
+ ******************************************************************************/
+
+package goflogger
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/atcharles/gof/gofconf"
+	"github.com/sirupsen/logrus"
+)
+
+func TestSyslogUDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+	logger := logrus.New()
+	logger.Out = ioutil.Discard
+	sink := SyslogSink("udp", pc.LocalAddr().String(), logrus.InfoLevel, &logrus.TextFormatter{DisableTimestamp: true})
+	logger.AddHook(sink)
+	logger.Debug("filtered by the sink")
+	logger.Error("hello")
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+	pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+	b := make([]byte, 2048)
+	n, _, err := pc.ReadFrom(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := string(b[:n])
+	// facility user (1) * 8 + severity error (3)
+	if !strings.HasPrefix(msg, "<11>1 ") || !strings.HasSuffix(msg, `level=error msg=hello`) {
+		t.Fatalf("unexpected message %q", msg)
+	}
+}
+
+func TestSyslogTCPFraming(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	received := make(chan []byte, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			received <- nil
+			return
+		}
+		defer conn.Close()
+		b, _ := ioutil.ReadAll(conn)
+		received <- b
+	}()
+	w := NewSyslogWriter("tcp", ln.Addr().String())
+	for i := 0; i < 3; i++ {
+		w.WriteLevel(logrus.WarnLevel, []byte(fmt.Sprintf("line %d\nwith a newline\n", i)))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	var b []byte
+	select {
+	case b = <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the server received nothing")
+	}
+	// octet counting: MSG-LEN SP SYSLOG-MSG
+	r := bufio.NewReader(bytes.NewReader(b))
+	for i := 0; i < 3; i++ {
+		size, err := r.ReadString(' ')
+		if err != nil {
+			t.Fatalf("message %d: %s", i, err)
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(size))
+		if err != nil {
+			t.Fatalf("message %d: bad length %q", i, size)
+		}
+		msg := make([]byte, n)
+		if _, err := io.ReadFull(r, msg); err != nil {
+			t.Fatalf("message %d: %s", i, err)
+		}
+		want := fmt.Sprintf("line %d\nwith a newline", i)
+		if !bytes.HasPrefix(msg, []byte("<12>1 ")) || !bytes.HasSuffix(msg, []byte(want)) {
+			t.Fatalf("unexpected message %q", msg)
+		}
+	}
+	if rest, _ := ioutil.ReadAll(r); len(rest) > 0 {
+		t.Fatalf("unexpected trailing bytes %q", rest)
+	}
+}
+
+func TestSyslogSlowServerDoesNotBlock(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	// the server accepts and never reads
+	var conns []net.Conn
+	var mu sync.Mutex
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			conns = append(conns, conn)
+			mu.Unlock()
+		}
+	}()
+	defer func() {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, conn := range conns {
+			conn.Close()
+		}
+	}()
+	w := NewSyslogWriter("tcp", ln.Addr().String())
+	w.Timeout = 300 * time.Millisecond
+	w.QueueSize = 4
+	msg := bytes.Repeat([]byte("x"), 64*1024)
+	start := time.Now()
+	for i := 0; i < 200; i++ {
+		if _, err := w.WriteLevel(logrus.InfoLevel, msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("the writes took %s", d)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if w.Dropped() == 0 {
+		t.Fatal("no message was dropped")
+	}
+}
+
+func TestShipperHTTP(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		lines    []string
+		requests int32
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the first batch is refused and retried
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.Header.Get("Content-Type") != "application/x-ndjson" || r.Header.Get("Authorization") != "Bearer t" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		b, _ := ioutil.ReadAll(r.Body)
+		mu.Lock()
+		lines = append(lines, strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")...)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+	s, err := NewShipper(srv.URL, ShipperOptions{
+		BatchSize:     10,
+		FlushInterval: 50 * time.Millisecond,
+		Backoff:       10 * time.Millisecond,
+		Header:        http.Header{"Authorization": {"Bearer t"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 25; i++ {
+		fmt.Fprintf(s, "{\"n\":%d}\n", i)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if sent, dropped := s.Sent(); sent != 25 || dropped != 0 {
+		t.Fatalf("sent %d dropped %d, want 25 and 0", sent, dropped)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(lines) != 25 {
+		t.Fatalf("the collector received %d lines, want 25", len(lines))
+	}
+	for i, line := range lines {
+		if want := fmt.Sprintf("{\"n\":%d}", i); line != want {
+			t.Fatalf("line %d is %q, want %q", i, line, want)
+		}
+	}
+}
+
+func TestShipperTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	received := make(chan []byte, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			received <- nil
+			return
+		}
+		defer conn.Close()
+		b, _ := ioutil.ReadAll(conn)
+		received <- b
+	}()
+	s, err := NewShipper("tcp://"+ln.Addr().String(), ShipperOptions{BatchSize: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		fmt.Fprintf(s, "line %d\n", i)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	var b []byte
+	select {
+	case b = <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the server received nothing")
+	}
+	lines := strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+	if len(lines) != 20 || lines[19] != "line 19" {
+		t.Fatalf("the server received %d lines, want 20", len(lines))
+	}
+}
+
+func TestShipperUnreachableDrops(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	s, err := NewShipper("http://"+addr, ShipperOptions{BatchSize: 5, MaxRetries: 2, Backoff: 5 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		fmt.Fprintf(s, "{\"n\":%d}\n", i)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if sent, dropped := s.Sent(); sent != 0 || dropped != 5 {
+		t.Fatalf("sent %d dropped %d, want 0 and 5", sent, dropped)
+	}
+}
+
+func TestConsoleOptIn(t *testing.T) {
+	out := new(lockedBuffer)
+	consoleOut = out
+	defer func() {
+		consoleOut = os.Stdout
+	}()
+	gofconf.DefaultLog.ConsoleEnable = true
+	defer func() {
+		gofconf.DefaultLog.ConsoleEnable = false
+	}()
+	fl := newTestFile(t, Policy{})
+	fl.Logger.Info("file only")
+	fl.SetConsole(true)
+	fl.Logger.Info("file and console")
+	got := out.buf.String()
+	if strings.Contains(got, "file only") || !strings.Contains(got, "file and console") {
+		t.Fatalf("unexpected console output %q", got)
+	}
+}
@@ -0,0 +1,230 @@
+/*******************************************************************************
+ * Copyright (c) 2018  charles
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NON INFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ * -------------------------------------------------------------------------
+ * created at 2026-11-03 16:25:30
+ ******************************************************************************/
+
+package goflogger
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/atcharles/gof/gofutils"
+	"github.com/sirupsen/logrus"
+)
+
+// syslog facilities, see RFC 5424
+const (
+	FacilityUser   = 1
+	FacilityDaemon = 3
+	FacilityLocal0 = 16
+)
+
+// SyslogWriter writes RFC 5424 messages over udp, tcp, unix (stream) or unixgram.
+// The stream transports use the octet counting framing of RFC 6587.
+// The messages are queued and written in the background, so a slow or unreachable server
+// never blocks the logger; the connection is dialed on the first message and again after a write error.
+type SyslogWriter struct {
+	dropped uint64
+
+	Network  string
+	Addr     string
+	Facility int
+	// AppName defaults to the program name
+	AppName  string
+	Hostname string
+	// Timeout of the dial and of a write, it defaults to 5s
+	Timeout time.Duration
+	// QueueSize is the number of messages waiting to be written, the newest are dropped when it is full,
+	// it defaults to 1024
+	QueueSize int
+
+	once    sync.Once
+	queue   chan []byte
+	done    chan struct{}
+	closeMu sync.RWMutex
+	closed  bool
+	conn    net.Conn
+	failing bool
+}
+
+// NewSyslogWriter e.g. NewSyslogWriter("udp", "127.0.0.1:514") or NewSyslogWriter("unixgram", "/dev/log")
+func NewSyslogWriter(network, addr string) *SyslogWriter {
+	host, _ := os.Hostname()
+	return &SyslogWriter{
+		Network:   network,
+		Addr:      addr,
+		Facility:  FacilityUser,
+		AppName:   filepath.Base(gofutils.SelfPath()),
+		Hostname:  host,
+		Timeout:   5 * time.Second,
+		QueueSize: 1024,
+	}
+}
+
+// SyslogSink returns a sink writing to a SyslogWriter, the formatter writes the MSG part
+func SyslogSink(network, addr string, level logrus.Level, formatter logrus.Formatter) *Sink {
+	return &Sink{
+		Name:      "syslog:" + network + ":" + addr,
+		Level:     level,
+		Formatter: formatter,
+		Writer:    NewSyslogWriter(network, addr),
+	}
+}
+
+// severity maps the logrus levels to the syslog severities
+func severity(level logrus.Level) int {
+	switch level {
+	case logrus.PanicLevel:
+		return 1 // alert
+	case logrus.FatalLevel:
+		return 2 // critical
+	case logrus.ErrorLevel:
+		return 3
+	case logrus.WarnLevel:
+		return 4
+	case logrus.InfoLevel:
+		return 6
+	}
+	return 7 // debug
+}
+
+// Write writes p at the info severity, see WriteLevel
+func (w *SyslogWriter) Write(p []byte) (int, error) {
+	return w.WriteLevel(logrus.InfoLevel, p)
+}
+
+// WriteLevel queues p as the MSG of one message without waiting, p is dropped when the queue is full
+func (w *SyslogWriter) WriteLevel(level logrus.Level, p []byte) (int, error) {
+	w.once.Do(w.start)
+	w.closeMu.RLock()
+	defer w.closeMu.RUnlock()
+	if w.closed {
+		atomic.AddUint64(&w.dropped, 1)
+		return len(p), nil
+	}
+	select {
+	case w.queue <- w.format(level, p):
+	default:
+		atomic.AddUint64(&w.dropped, 1)
+	}
+	return len(p), nil
+}
+
+// Dropped returns the number of messages dropped because the queue was full or the server failed
+func (w *SyslogWriter) Dropped() uint64 {
+	return atomic.LoadUint64(&w.dropped)
+}
+
+func (w *SyslogWriter) start() {
+	size := w.QueueSize
+	if size < 1 {
+		size = 1024
+	}
+	if w.Timeout <= 0 {
+		w.Timeout = 5 * time.Second
+	}
+	w.queue = make(chan []byte, size)
+	w.done = make(chan struct{})
+	go w.run()
+}
+
+func (w *SyslogWriter) run() {
+	defer close(w.done)
+	for msg := range w.queue {
+		w.send(msg)
+	}
+	if w.conn != nil {
+		w.conn.Close()
+		w.conn = nil
+	}
+}
+
+// send writes the message with one retry on a new connection, e.g. after a restart of the server.
+// The first failure after a success is logged, the message is dropped.
+func (w *SyslogWriter) send(msg []byte) {
+	var err error
+	for i := 0; i < 2; i++ {
+		if w.conn == nil {
+			if w.conn, err = net.DialTimeout(w.Network, w.Addr, w.Timeout); err != nil {
+				w.conn = nil
+				break
+			}
+		}
+		w.conn.SetWriteDeadline(time.Now().Add(w.Timeout))
+		if _, err = w.conn.Write(msg); err == nil {
+			w.failing = false
+			return
+		}
+		w.conn.Close()
+		w.conn = nil
+	}
+	atomic.AddUint64(&w.dropped, 1)
+	if !w.failing {
+		w.failing = true
+		log.SetFlags(log.LstdFlags)
+		log.Printf("failed to write to syslog %s %s err:%s\n", w.Network, w.Addr, err.Error())
+	}
+}
+
+func (w *SyslogWriter) format(level logrus.Level, p []byte) []byte {
+	nilValue := func(s string) string {
+		if s == "" {
+			return "-"
+		}
+		return s
+	}
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "<%d>1 %s %s %s %d - - ",
+		w.Facility*8+severity(level),
+		time.Now().Format("2006-01-02T15:04:05.000000Z07:00"),
+		nilValue(w.Hostname),
+		nilValue(w.AppName),
+		os.Getpid(),
+	)
+	buf.Write(bytes.TrimRight(p, "\n"))
+	if strings.HasPrefix(w.Network, "tcp") || w.Network == "unix" {
+		return append([]byte(fmt.Sprintf("%d ", buf.Len())), buf.Bytes()...)
+	}
+	return buf.Bytes()
+}
+
+// Close writes the queued messages and closes the connection
+func (w *SyslogWriter) Close() error {
+	w.once.Do(w.start)
+	w.closeMu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.queue)
+	}
+	w.closeMu.Unlock()
+	<-w.done
+	return nil
+}
@@ -0,0 +1,224 @@
+/*******************************************************************************
+ * Copyright (c) 2018  charles
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NON INFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ * -------------------------------------------------------------------------
+ * created at 2026-11-04 15:33:58
+ ******************************************************************************/
+
+package gofconfmiddleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/atcharles/gof/gofconf"
+	"github.com/atcharles/gof/goflogger"
+	"github.com/atcharles/gof/gofutils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// formats of the access log
+const (
+	AccessLogCombined = "combined"
+	AccessLogJSON     = "json"
+)
+
+type (
+	// AccessLogConfig defines the config for AccessLog middleware.
+	AccessLogConfig struct {
+		// Format is AccessLogCombined or AccessLogJSON.
+		// The combined lines end with the latency in seconds and the request ID.
+		Format string `mapstructure:"format" yaml:"format" comment:"combined or json"`
+
+		// Path of the rotating log file, relative to the program directory unless it is absolute, see goflogger.File.
+		Path string `mapstructure:"path" yaml:"path" comment:"access log file, relative to the program directory unless absolute"`
+
+		// SkipPaths are not logged, e.g. the health checks.
+		SkipPaths []string `mapstructure:"skip_paths" yaml:"skip_paths" comment:"paths not logged, e.g. /health"`
+
+		// SlowThreshold marks the slower requests, they are always logged.
+		SlowThreshold time.Duration `mapstructure:"slow_threshold" yaml:"slow_threshold" comment:"requests slower than this are always logged and marked slow, e.g. 1s"`
+
+		// SampleRate is the fraction of the other requests logged, the errors are always logged.
+		// Optional. Default value 1.
+		SampleRate float64 `mapstructure:"sample_rate" yaml:"sample_rate" comment:"fraction of the fast and successful requests logged, 1 logs every request"`
+
+		// UserIDKey is the key of the user ID in the gin context.
+		UserIDKey string `mapstructure:"user_id_key" yaml:"user_id_key" comment:"key of the user ID in the gin context"`
+	}
+
+	// AccessEntry is a line of the json access log.
+	AccessEntry struct {
+		Time      string  `json:"time"`
+		Method    string  `json:"method"`
+		Path      string  `json:"path"`
+		Query     string  `json:"query,omitempty"`
+		Status    int     `json:"status"`
+		Latency   float64 `json:"latency_ms"`
+		Bytes     int     `json:"bytes"`
+		IP        string  `json:"ip"`
+		UserAgent string  `json:"user_agent"`
+		Referer   string  `json:"referer,omitempty"`
+		RequestID string  `json:"request_id,omitempty"`
+		UserID    string  `json:"user_id,omitempty"`
+		Slow      bool    `json:"slow,omitempty"`
+	}
+)
+
+// InitFunc ReadIn ...
+func (p *AccessLogConfig) InitFunc() error {
+	return gofconf.ReadObjInformation(&DefaultAccessLogConfig)
+}
+
+var (
+	// DefaultAccessLogConfig is the default access log middleware config.
+	DefaultAccessLogConfig = AccessLogConfig{
+		Format:        AccessLogCombined,
+		Path:          "logs/access/access.log",
+		SkipPaths:     []string{},
+		SlowThreshold: time.Second,
+		SampleRate:    1,
+		UserIDKey:     "user_id",
+	}
+)
+
+func init() {
+	gofconf.AddDefaultInformation(&DefaultAccessLogConfig)
+}
+
+// AccessLog logs every request to a rotating goflogger.File, it replaces gin.Logger.
+// Put it after RequestID to log the request IDs.
+func AccessLog(configs ...AccessLogConfig) gin.HandlerFunc {
+	var config AccessLogConfig
+	if len(configs) == 0 {
+		config = DefaultAccessLogConfig
+	} else {
+		config = configs[0]
+	}
+	if config.Format == "" {
+		config.Format = DefaultAccessLogConfig.Format
+	}
+	if config.Path == "" {
+		config.Path = DefaultAccessLogConfig.Path
+	}
+	if config.SampleRate <= 0 {
+		config.SampleRate = 1
+	}
+	if config.UserIDKey == "" {
+		config.UserIDKey = DefaultAccessLogConfig.UserIDKey
+	}
+	skip := make(map[string]bool, len(config.SkipPaths))
+	for _, p := range config.SkipPaths {
+		skip[p] = true
+	}
+	name := config.Path
+	if !filepath.IsAbs(name) {
+		name = gofutils.SelfDir() + name
+	}
+	file := goflogger.GetFile(name)
+	return func(c *gin.Context) {
+		start := time.Now()
+		path := c.Request.URL.Path
+		c.Next()
+		if skip[path] {
+			return
+		}
+		latency := time.Since(start)
+		status := c.Writer.Status()
+		slow := config.SlowThreshold > 0 && latency >= config.SlowThreshold
+		if !slow && status < http.StatusBadRequest && config.SampleRate < 1 && rand.Float64() >= config.SampleRate {
+			return
+		}
+		level := logrus.InfoLevel
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = logrus.ErrorLevel
+		case status >= http.StatusBadRequest || slow:
+			level = logrus.WarnLevel
+		}
+		size := c.Writer.Size()
+		if size < 0 {
+			size = 0
+		}
+		var userID string
+		if v, ok := c.Get(config.UserIDKey); ok {
+			userID = fmt.Sprint(v)
+		}
+		var line []byte
+		if config.Format == AccessLogJSON {
+			b, err := json.Marshal(AccessEntry{
+				Time:      start.Format(time.RFC3339Nano),
+				Method:    c.Request.Method,
+				Path:      path,
+				Query:     c.Request.URL.RawQuery,
+				Status:    status,
+				Latency:   float64(latency.Nanoseconds()/1e3) / 1e3,
+				Bytes:     size,
+				IP:        c.ClientIP(),
+				UserAgent: c.Request.UserAgent(),
+				Referer:   c.Request.Referer(),
+				RequestID: GetRequestID(c),
+				UserID:    userID,
+				Slow:      slow,
+			})
+			if err != nil {
+				return
+			}
+			line = append(b, '\n')
+		} else {
+			line = []byte(combinedLine(c, start, latency, userID))
+		}
+		file.WriteLevel(level, line)
+	}
+}
+
+// combinedLine is the Combined Log Format followed by the latency in seconds and the request ID.
+func combinedLine(c *gin.Context, start time.Time, latency time.Duration, userID string) string {
+	dash := func(s string) string {
+		if s == "" {
+			return "-"
+		}
+		return s
+	}
+	size := "-"
+	if n := c.Writer.Size(); n > 0 {
+		size = fmt.Sprint(n)
+	}
+	return fmt.Sprintf("%s - %s [%s] \"%s %s %s\" %d %s \"%s\" \"%s\" %.3f \"%s\"\n",
+		c.ClientIP(),
+		dash(userID),
+		start.Format("02/Jan/2006:15:04:05 -0700"),
+		c.Request.Method,
+		c.Request.RequestURI,
+		c.Request.Proto,
+		c.Writer.Status(),
+		size,
+		dash(strings.Replace(c.Request.Referer(), "\"", "\\\"", -1)),
+		dash(strings.Replace(c.Request.UserAgent(), "\"", "\\\"", -1)),
+		latency.Seconds(),
+		dash(GetRequestID(c)),
+	)
+}
@@ -0,0 +1,115 @@
+/*******************************************************************************
+ * Copyright (c) 2018  charles
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NON INFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ * -------------------------------------------------------------------------
+ * created at 2026-11-08 15:40:12
+ ******************************************************************************/
+
+package gofconfmiddleware
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/atcharles/gof/goflogger"
+	"github.com/gin-gonic/gin"
+)
+
+// accessLog serves the requests behind AccessLog and returns the paths of the logged entries
+func accessLog(t *testing.T, config AccessLogConfig, paths ...string) []string {
+	gin.SetMode(gin.TestMode)
+	config.Format = AccessLogJSON
+	config.Path = filepath.Join(t.TempDir(), "access.log")
+	r := gin.New()
+	r.Use(AccessLog(config))
+	r.GET("/ok", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+	r.GET("/bad", func(c *gin.Context) { c.String(http.StatusNotFound, "bad") })
+	r.GET("/fail", func(c *gin.Context) { c.String(http.StatusInternalServerError, "fail") })
+	r.GET("/slow", func(c *gin.Context) {
+		time.Sleep(30 * time.Millisecond)
+		c.String(http.StatusOK, "slow")
+	})
+	r.GET("/health", func(c *gin.Context) { c.String(http.StatusInternalServerError, "down") })
+	for _, p := range paths {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, p, nil))
+	}
+	fl := goflogger.GetFile(config.Path)
+	if err := fl.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(config.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var logged []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var e AccessEntry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			t.Fatalf("bad line %q: %v", sc.Text(), err)
+		}
+		logged = append(logged, e.Path)
+	}
+	return logged
+}
+
+func TestAccessLogSampling(t *testing.T) {
+	paths := []string{"/ok", "/bad", "/fail", "/slow", "/health"}
+	tests := []struct {
+		name   string
+		config AccessLogConfig
+		logged []string
+	}{
+		{
+			name:   "every request",
+			config: AccessLogConfig{SkipPaths: []string{"/health"}},
+			logged: []string{"/ok", "/bad", "/fail", "/slow"},
+		},
+		{
+			name: "errors and slow requests",
+			config: AccessLogConfig{
+				SkipPaths:     []string{"/health"},
+				SlowThreshold: 20 * time.Millisecond,
+				SampleRate:    1e-12,
+			},
+			logged: []string{"/bad", "/fail", "/slow"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logged := accessLog(t, tt.config, paths...)
+			if len(logged) != len(tt.logged) {
+				t.Fatalf("logged %v, want %v", logged, tt.logged)
+			}
+			for i := range logged {
+				if logged[i] != tt.logged[i] {
+					t.Fatalf("logged %v, want %v", logged, tt.logged)
+				}
+			}
+		})
+	}
+}
@@ -38,34 +38,34 @@ type (
 	CORSConfig struct {
 		// AllowOrigin defines a list of origins that may access the resource.
 		// Optional. Default value []string{"*"}.
-		AllowOrigins []string `mapstructure:"allow_origins" yaml:"allow_origins"`
+		AllowOrigins []string `mapstructure:"allow_origins" yaml:"allow_origins" comment:"origins that may access the resource, * allows any origin"`
 
 		// AllowMethods defines a list methods allowed when accessing the resource.
 		// This is used in response to a preflight request.
 		// Optional. Default value DefaultCORSConfig.AllowMethods.
-		AllowMethods []string `mapstructure:"allow_methods" yaml:"allow_methods"`
+		AllowMethods []string `mapstructure:"allow_methods" yaml:"allow_methods" comment:"methods allowed when accessing the resource"`
 
 		// AllowHeaders defines a list of request headers that can be used when
 		// making the actual request. This in response to a preflight request.
 		// Optional. Default value []string{}.
-		AllowHeaders []string `mapstructure:"allow_headers" yaml:"allow_headers"`
+		AllowHeaders []string `mapstructure:"allow_headers" yaml:"allow_headers" comment:"request headers that can be used in the actual request, empty allows the requested ones"`
 
 		// AllowCredentials indicates whether or not the response to the request
 		// can be exposed when the credentials flag is true. When used as part of
 		// a response to a preflight request, this indicates whether or not the
 		// actual request can be made using credentials.
 		// Optional. Default value false.
-		AllowCredentials bool `mapstructure:"allow_credentials" yaml:"allow_credentials"`
+		AllowCredentials bool `mapstructure:"allow_credentials" yaml:"allow_credentials" comment:"whether the request can be made using credentials"`
 
 		// ExposeHeaders defines a whitelist headers that clients are allowed to
 		// access.
 		// Optional. Default value []string{}.
-		ExposeHeaders []string `mapstructure:"expose_headers" yaml:"expose_headers"`
+		ExposeHeaders []string `mapstructure:"expose_headers" yaml:"expose_headers" comment:"headers that clients are allowed to access"`
 
 		// MaxAge indicates how long (in seconds) the results of a preflight request
 		// can be cached.
 		// Optional. Default value 0.
-		MaxAge int `mapstructure:"max_age" yaml:"max_age"`
+		MaxAge int `mapstructure:"max_age" yaml:"max_age" comment:"how long (in seconds) the results of a preflight request can be cached"`
 	}
 )
 
@@ -0,0 +1,156 @@
+/*******************************************************************************
+ * Copyright (c) 2018  charles
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NON INFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ * -------------------------------------------------------------------------
+ * created at 2026-10-24 15:08:49
+ ******************************************************************************/
+
+package gofconfmiddleware
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+
+	"github.com/atcharles/gof/gofconf"
+	"github.com/atcharles/gof/gofi18n"
+	"github.com/atcharles/gof/goflogger"
+	"github.com/atcharles/gof/gofutils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type (
+	// HandlerFunc is a gin handler returning an error, see Handle.
+	HandlerFunc func(c *gin.Context) error
+
+	// ErrorBody is the body rendered by ErrorHandler.
+	ErrorBody struct {
+		XMLName   xml.Name    `json:"-" xml:"error"`
+		Code      int         `json:"code" xml:"code"`
+		Message   interface{} `json:"message" xml:"message"`
+		RequestID string      `json:"request_id,omitempty" xml:"request_id,omitempty"`
+		Details   interface{} `json:"details,omitempty" xml:"details,omitempty"`
+	}
+
+	// ErrorConfig defines the config for ErrorHandler middleware.
+	ErrorConfig struct {
+		// Logger receives the internal errors.
+		// Optional. Default value the logger of logs/error/error.log.
+		Logger *goflogger.Logger
+	}
+)
+
+// ErrorLogger returns the logger of logs/error/error.log, the file is opened on the first call.
+var ErrorLogger = func() *goflogger.Logger {
+	return goflogger.GetFile(gofutils.SelfDir() + "logs/error/error.log").GetLogger()
+}
+
+// Handle adapts a handler returning an error, the error is attached to the context for ErrorHandler.
+func Handle(h HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := h(c); err != nil {
+			c.Error(err)
+			c.Abort()
+		}
+	}
+}
+
+// ErrorHandler renders the last error attached to the context as JSON, or XML when the client accepts it.
+// A *gofconf.HTTPError is rendered as is, a bind error is a 400 and any other error is a 500
+// without its message. The code of the body is BizCode when it is set, and the message of
+// MessageKey is translated for Accept-Language, see gofi18n. The internal errors are logged, nothing is rendered when the response is written.
+func ErrorHandler(configs ...ErrorConfig) gin.HandlerFunc {
+	var config ErrorConfig
+	if len(configs) > 0 {
+		config = configs[0]
+	}
+	return func(c *gin.Context) {
+		c.Next()
+		if len(c.Errors) == 0 {
+			return
+		}
+		ge := c.Errors.Last()
+		he := toHTTPError(ge)
+		if he.Internal != nil || he.Code >= http.StatusInternalServerError {
+			logger := config.Logger
+			if logger == nil {
+				logger = ErrorLogger()
+			}
+			internal := he.Internal
+			if internal == nil {
+				internal = he
+			}
+			logger.WithFields(logrus.Fields{
+				"request_id": GetRequestID(c),
+				"method":     c.Request.Method,
+				"path":       c.Request.URL.Path,
+				"status":     he.Code,
+			}).Error(internal.Error())
+		}
+		if c.Writer.Written() {
+			return
+		}
+		if he.MessageKey != "" {
+			lang := gofi18n.Match(c.GetHeader(gofconf.HeaderAcceptLanguage))
+			he = gofi18n.Localize(he, lang)
+			c.Header(gofconf.HeaderContentLanguage, lang)
+		}
+		code := he.Code
+		if he.BizCode != 0 {
+			code = he.BizCode
+		}
+		body := ErrorBody{
+			Code:      code,
+			Message:   he.Message,
+			RequestID: GetRequestID(c),
+			Details:   he.Details,
+		}
+		switch c.NegotiateFormat(gin.MIMEJSON, gin.MIMEXML, gin.MIMEXML2) {
+		case gin.MIMEXML, gin.MIMEXML2:
+			body.Message = xmlValue(body.Message)
+			body.Details = xmlValue(body.Details)
+			c.XML(he.Code, body)
+		default:
+			c.JSON(he.Code, body)
+		}
+	}
+}
+
+func toHTTPError(ge *gin.Error) *gofconf.HTTPError {
+	if he, ok := ge.Err.(*gofconf.HTTPError); ok {
+		return he
+	}
+	if ge.IsType(gin.ErrorTypeBind) {
+		return gofconf.NewHTTPError(http.StatusBadRequest).WithDetails(ge.Err.Error())
+	}
+	return gofconf.NewHTTPError(http.StatusInternalServerError).WithInternal(ge.Err)
+}
+
+// xmlValue keeps the values encoding/xml can marshal, other values are rendered by fmt.
+func xmlValue(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	if _, err := xml.Marshal(v); err != nil {
+		return fmt.Sprint(v)
+	}
+	return v
+}
@@ -0,0 +1,141 @@
+/*******************************************************************************
+ * Copyright (c) 2018  charles
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NON INFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ * -------------------------------------------------------------------------
+ * created at 2026-11-08 13:35:08
+ ******************************************************************************/
+
+package gofconfmiddleware
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/atcharles/gof/gofconf"
+	"github.com/atcharles/gof/goflogger"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// errorRouter serves h behind ErrorHandler, the internal errors are logged to the returned buffer
+func errorRouter(h gin.HandlerFunc) (*gin.Engine, *bytes.Buffer) {
+	gin.SetMode(gin.TestMode)
+	out := new(bytes.Buffer)
+	logger := logrus.New()
+	logger.Out = out
+	r := gin.New()
+	r.Use(RequestID(), ErrorHandler(ErrorConfig{Logger: &goflogger.Logger{Logger: logger}}))
+	r.GET("/", h)
+	return r, out
+}
+
+func TestErrorHandler(t *testing.T) {
+	tests := []struct {
+		name   string
+		accept string
+		h      gin.HandlerFunc
+		status int
+		body   string
+		logged string
+	}{
+		{
+			name: "http error",
+			h: Handle(func(c *gin.Context) error {
+				return gofconf.NewHTTPError(http.StatusNotFound, "no such user")
+			}),
+			status: http.StatusNotFound,
+			body:   `{"code":404,"message":"no such user","request_id":"req-1"}`,
+		},
+		{
+			name: "business code",
+			h: Handle(func(c *gin.Context) error {
+				he := gofconf.NewHTTPError(http.StatusConflict, "taken")
+				he.BizCode = 40901
+				return he
+			}),
+			status: http.StatusConflict,
+			body:   `{"code":40901,"message":"taken","request_id":"req-1"}`,
+		},
+		{
+			name: "bind error",
+			h: func(c *gin.Context) {
+				c.Error(errors.New("name is required")).SetType(gin.ErrorTypeBind)
+			},
+			status: http.StatusBadRequest,
+			body:   `{"code":400,"message":"Bad Request","request_id":"req-1","details":"name is required"}`,
+		},
+		{
+			name: "internal error",
+			h: Handle(func(c *gin.Context) error {
+				return errors.New("db password rejected")
+			}),
+			status: http.StatusInternalServerError,
+			body:   `{"code":500,"message":"Internal Server Error","request_id":"req-1"}`,
+			logged: "db password rejected",
+		},
+		{
+			name:   "xml",
+			accept: "application/xml",
+			h: Handle(func(c *gin.Context) error {
+				return gofconf.NewHTTPError(http.StatusForbidden)
+			}),
+			status: http.StatusForbidden,
+			body:   `<error><code>403</code><message>Forbidden</message><request_id>req-1</request_id></error>`,
+		},
+		{
+			name: "written response",
+			h: func(c *gin.Context) {
+				c.String(http.StatusOK, "partial")
+				c.Error(errors.New("late failure"))
+			},
+			status: http.StatusOK,
+			body:   "partial",
+			logged: "late failure",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, logged := errorRouter(tt.h)
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(gofconf.HeaderXRequestID, "req-1")
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.status || strings.TrimSpace(w.Body.String()) != tt.body {
+				t.Fatalf("got %d %s, want %d %s", w.Code, w.Body.String(), tt.status, tt.body)
+			}
+			if tt.logged == "" {
+				if logged.Len() > 0 {
+					t.Fatalf("unexpected log %s", logged.String())
+				}
+				return
+			}
+			if !strings.Contains(logged.String(), tt.logged) || !strings.Contains(logged.String(), "request_id=req-1") {
+				t.Fatalf("log %q, want the internal error with the request id", logged.String())
+			}
+		})
+	}
+}
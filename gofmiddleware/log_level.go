@@ -0,0 +1,88 @@
+/*******************************************************************************
+ * Copyright (c) 2018  charles
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NON INFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ * -------------------------------------------------------------------------
+ * created at 2026-11-01 11:42:09
+ ******************************************************************************/
+
+package gofconfmiddleware
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/atcharles/gof/gofconf"
+	"github.com/atcharles/gof/goflogger"
+	"github.com/gin-gonic/gin"
+)
+
+// LogLevelBody is the body of PUT /log/levels/:module.
+type LogLevelBody struct {
+	Level string `json:"level" binding:"required"`
+}
+
+// LogLevels registers the endpoints of the module log levels on r, see goflogger.Module:
+//
+//	GET    /log/levels          lists the modules and their levels
+//	PUT    /log/levels/:module  sets the level of a module, {"level": "debug"}
+//	DELETE /log/levels/:module  gives the module the configured level back
+//
+// Every request needs the header `Authorization: Bearer <token>`,
+// the routes are not registered without a token.
+func LogLevels(r gin.IRouter, token string) error {
+	if token == "" {
+		return errors.New("log levels: a bearer token is required")
+	}
+	g := r.Group("/log/levels", ErrorHandler(), bearerAuth(token))
+	g.GET("", func(c *gin.Context) {
+		c.JSON(http.StatusOK, goflogger.Levels())
+	})
+	g.PUT("/:module", Handle(func(c *gin.Context) error {
+		var body LogLevelBody
+		if err := c.ShouldBindJSON(&body); err != nil {
+			return gofconf.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		if err := goflogger.SetLevel(c.Param("module"), body.Level); err != nil {
+			return gofconf.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		c.JSON(http.StatusOK, goflogger.Levels())
+		return nil
+	}))
+	g.DELETE("/:module", func(c *gin.Context) {
+		goflogger.ResetLevel(c.Param("module"))
+		c.JSON(http.StatusOK, goflogger.Levels())
+	})
+	return nil
+}
+
+// bearerAuth rejects the requests without the token.
+func bearerAuth(token string) gin.HandlerFunc {
+	return Handle(func(c *gin.Context) error {
+		header := c.GetHeader(gofconf.HeaderAuthorization)
+		got := strings.TrimPrefix(header, "Bearer ")
+		if got == header || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			c.Header(gofconf.HeaderWWWAuthenticate, "Bearer")
+			return gofconf.ErrUnauthorized
+		}
+		return nil
+	})
+}
@@ -0,0 +1,73 @@
+/*******************************************************************************
+ * Copyright (c) 2018  charles
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NON INFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ * -------------------------------------------------------------------------
+ * created at 2026-10-24 14:36:02
+ ******************************************************************************/
+
+package gofconfmiddleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"time"
+
+	"github.com/atcharles/gof/gofconf"
+	"github.com/atcharles/gof/goflogger"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// RequestIDKey is the key of the request ID in the gin context.
+const RequestIDKey = "request_id"
+
+// RequestID keeps the X-Request-ID header of the request or generates one,
+// the ID is stored in the context and written back in the response header.
+// The request context carries the ID for goflogger, see Logger.Ctx.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(gofconf.HeaderXRequestID)
+		if id == "" {
+			id = newRequestID()
+		}
+		c.Set(RequestIDKey, id)
+		c.Header(gofconf.HeaderXRequestID, id)
+		ctx := goflogger.WithFields(c.Request.Context(), logrus.Fields{RequestIDKey: id})
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// GetRequestID returns the ID set by RequestID, or the X-Request-ID header of the request.
+func GetRequestID(c *gin.Context) string {
+	if id := c.GetString(RequestIDKey); id != "" {
+		return id
+	}
+	return c.GetHeader(gofconf.HeaderXRequestID)
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(b)
+}
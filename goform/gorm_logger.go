@@ -31,6 +31,7 @@ import (
 	"reflect"
 	"regexp"
 	"strconv"
+	"sync/atomic"
 	"time"
 	"unicode"
 
@@ -40,14 +41,16 @@ import (
 )
 
 var (
-	f = func() *goflogger.File {
+	// sqlLogger is the sql module, its level is set in the configuration, see goflogger.Module
+	sqlLogger = goflogger.Module("sql")
+	f         = func() *goflogger.File {
 		file := goflogger.GetFile(gofutils.SelfDir() + "logs/sql/sql.log")
-		file.Logger.Formatter = &logrus.TextFormatter{
+		file.SetFormatter(&logrus.TextFormatter{
 			TimestampFormat: "2006-01-02 15:04:05.000",
-		}
+		})
 		return file
 	}
-	defLogFile               = f().GetFile()
+	defLogFile               = f()
 	defaultLogger            = Logger{log.New(defLogFile, "\r\n", 0)}
 	sqlRegexp                = regexp.MustCompile(`\?`)
 	numericPlaceHolderRegexp = regexp.MustCompile(`\$\d+`)
@@ -145,7 +148,11 @@ type Logger struct {
 	LogWriter
 }
 
-// Print format & print log
+// Print format & print log, the statements are logged at the info level of the sql module
 func (logger Logger) Print(values ...interface{}) {
+	// the level is read like logrus does, SetLevel stores it atomically
+	if logrus.Level(atomic.LoadUint32((*uint32)(&sqlLogger.Level))) < logrus.InfoLevel {
+		return
+	}
 	logger.Println(LogFormatter(values...)...)
 }
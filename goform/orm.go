@@ -25,6 +25,7 @@
 package goform
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -40,7 +41,7 @@ import (
 var (
 	Slave       *gorm.DB
 	Master      *gorm.DB
-	configName  = "database.yaml"
+	configName  = "database"
 	vp          = viper.New()
 	defaultConf = Conf{
 		ShowSQL: true,
@@ -54,7 +55,8 @@ var (
 		},
 		Slave: Database{Address: "127.0.0.1", Port: 5432},
 	}
-	dbs = make([]*gorm.DB, 0)
+	dbs      = make([]*gorm.DB, 0)
+	pingQuit = make(chan struct{})
 )
 
 type (
@@ -70,37 +72,73 @@ type (
 		Address  string
 		Port     int
 		User     string `yaml:",omitempty"`
-		Password string `yaml:",omitempty"`
+		Password string `yaml:",omitempty" secret:"true"`
 		DB       string `yaml:",omitempty"`
 	}
 )
 
+//Initialize connects the databases, it panics on error, see Start
 func Initialize() {
-	if err := settingDatabase(); err != nil {
+	if err := Start(); err != nil {
 		panic(err.Error())
 	}
 }
 
+//Start connects the databases
+func Start() error {
+	return settingDatabase()
+}
+
+//Stop stops the keepalive and closes the connections,
+//the statements already running are completed first
+func Stop(ctx context.Context) error {
+	select {
+	case <-pingQuit:
+	default:
+		close(pingQuit)
+	}
+	var errs error
+	for _, db := range dbs {
+		errs = errors.Append(errs, db.Close())
+	}
+	return errs
+}
+
 //readConf ... Read configuration information
 func readConf() error {
-	fileName := gofutils.SelfDir() + "conf/" + configName
-	if err := gofutils.TouchFile(fileName); err != nil {
-		return err
+	// The database file lives next to the global file and uses its format
+	fileName := gofconf.ConfigPath(configName + gofconf.ConfigExt())
+	ptr := &defaultConf
+	key := gofutils.SnakeString(gofutils.ObjectName(ptr))
+	if gofconf.IsBootstrap() {
+		if err := gofconf.TouchConfigFile(fileName); err != nil {
+			return err
+		}
 	}
 	vp.SetConfigFile(fileName)
-	if err := vp.ReadInConfig(); err != nil {
-		return err
+	if gofutils.FileExists(fileName) {
+		if err := vp.ReadInConfig(); err != nil {
+			return err
+		}
 	}
-	ptr := &defaultConf
-	key := gofutils.SnakeString(gofutils.ObjectName(ptr))
 	if vp.IsSet(key) {
-		return vp.UnmarshalKey(key, ptr)
-	}
-	vp.Set(key, ptr)
-	if err := vp.WriteConfig(); err != nil {
-		return err
+		if err := vp.UnmarshalKey(key, ptr); err != nil {
+			return err
+		}
+	} else if !gofconf.IsBootstrap() {
+		gofconf.WarnDefaults(fileName, key)
+	} else {
+		value, err := gofconf.EncryptSecrets(ptr)
+		if err != nil {
+			return err
+		}
+		vp.Set(key, value)
+		if err := vp.WriteConfig(); err != nil {
+			return err
+		}
 	}
-	return nil
+	// e.g. GOF_DATABASE_MASTER_PASSWORD
+	return gofconf.ResolveObj(gofconf.EnvName(configName), ptr)
 }
 
 //createDatabaseEngine  The database connection is created
@@ -183,16 +221,19 @@ func settingDatabase() error {
 
 		db.SetLogger(defaultLogger)
 	}
-	gofconf.Job.JobQueue <- func() {
+	go func() {
 		tk := time.NewTicker(30 * time.Second)
+		defer tk.Stop()
 		for {
 			select {
+			case <-pingQuit:
+				return
 			case <-tk.C:
 				for _, db := range dbs {
 					db.DB().Ping()
 				}
 			}
 		}
-	}
+	}()
 	return nil
 }
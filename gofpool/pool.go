@@ -0,0 +1,276 @@
+/*******************************************************************************
+ * Copyright (c) 2018  charles
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NON INFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ * -------------------------------------------------------------------------
+ * created at 2026-10-21 15:20:37
+ ******************************************************************************/
+
+// Package gofpool is a bounded worker pool: jobs wait in a queue of a fixed size,
+// panics are recovered, and the pool drains the queued jobs when it is shut down.
+package gofpool
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/atcharles/gof/gofutils"
+)
+
+var (
+	// ErrQueueFull is returned by TrySubmit when the queue has no room
+	ErrQueueFull = errors.New("pool queue is full")
+	// ErrClosed is returned when the pool is shut down
+	ErrClosed = errors.New("pool is closed")
+
+	poolsMu sync.Mutex
+	pools   = make(map[string]*Pool)
+)
+
+// Job receives the context it was submitted with, a job whose context is done
+// before it starts is skipped.
+type Job func(ctx context.Context)
+
+type task struct {
+	ctx    context.Context
+	job    Job
+	queued time.Time
+}
+
+// Stats is a snapshot of the counters of a pool.
+type Stats struct {
+	Name      string
+	Workers   int
+	QueueSize int
+	// Queued is the current queue depth
+	Queued  int
+	Running int64
+
+	Submitted uint64
+	Rejected  uint64
+	Completed uint64
+	Canceled  uint64
+	Panicked  uint64
+
+	// AvgWait is the average time a job spends in the queue
+	AvgWait time.Duration
+	AvgRun  time.Duration
+	MaxRun  time.Duration
+}
+
+// Pool ...
+type Pool struct {
+	// 64-bit atomic counters first, for the alignment on 32-bit platforms
+	running   int64
+	submitted uint64
+	rejected  uint64
+	completed uint64
+	canceled  uint64
+	panicked  uint64
+	started   uint64
+	waitTotal int64
+	runTotal  int64
+	maxRun    int64
+
+	name    string
+	workers int
+	queue   chan task
+	quit    chan struct{}
+	once    sync.Once
+	mu      sync.RWMutex
+	closed  bool
+	wg      sync.WaitGroup
+}
+
+// New starts a pool of workers goroutines with a queue of queueSize jobs.
+// The pool is registered under its name, see AllStats.
+func New(name string, workers, queueSize int) *Pool {
+	if workers < 1 {
+		workers = 1
+	}
+	if queueSize < 0 {
+		queueSize = 0
+	}
+	p := &Pool{
+		name:    name,
+		workers: workers,
+		queue:   make(chan task, queueSize),
+		quit:    make(chan struct{}),
+	}
+	p.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go p.work()
+	}
+	poolsMu.Lock()
+	pools[name] = p
+	poolsMu.Unlock()
+	return p
+}
+
+// Name ...
+func (p *Pool) Name() string {
+	return p.name
+}
+
+// Submit queues the job, waiting for room until ctx is done.
+// The job receives ctx, it is skipped when ctx is done before it starts.
+func (p *Pool) Submit(ctx context.Context, job Job) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return ErrClosed
+	}
+	select {
+	case p.queue <- task{ctx: ctx, job: job, queued: time.Now()}:
+		atomic.AddUint64(&p.submitted, 1)
+		return nil
+	case <-ctx.Done():
+		atomic.AddUint64(&p.rejected, 1)
+		return ctx.Err()
+	case <-p.quit:
+		return ErrClosed
+	}
+}
+
+// TrySubmit queues the job without waiting, ErrQueueFull is returned when the queue has no room.
+func (p *Pool) TrySubmit(ctx context.Context, job Job) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return ErrClosed
+	}
+	select {
+	case p.queue <- task{ctx: ctx, job: job, queued: time.Now()}:
+		atomic.AddUint64(&p.submitted, 1)
+		return nil
+	default:
+		atomic.AddUint64(&p.rejected, 1)
+		return ErrQueueFull
+	}
+}
+
+// Go submits a function without a context, it is a shortcut of Submit with context.Background().
+func (p *Pool) Go(fn func()) error {
+	return p.Submit(context.Background(), func(context.Context) {
+		fn()
+	})
+}
+
+// Shutdown stops accepting jobs and waits until the queued and running jobs are done or ctx is done.
+func (p *Pool) Shutdown(ctx context.Context) error {
+	// release the blocked submitters before taking the lock
+	p.once.Do(func() { close(p.quit) })
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.queue)
+	}
+	p.mu.Unlock()
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Stats returns a snapshot of the counters.
+func (p *Pool) Stats() Stats {
+	s := Stats{
+		Name:      p.name,
+		Workers:   p.workers,
+		QueueSize: cap(p.queue),
+		Queued:    len(p.queue),
+		Running:   atomic.LoadInt64(&p.running),
+		Submitted: atomic.LoadUint64(&p.submitted),
+		Rejected:  atomic.LoadUint64(&p.rejected),
+		Completed: atomic.LoadUint64(&p.completed),
+		Canceled:  atomic.LoadUint64(&p.canceled),
+		Panicked:  atomic.LoadUint64(&p.panicked),
+		MaxRun:    time.Duration(atomic.LoadInt64(&p.maxRun)),
+	}
+	if started := atomic.LoadUint64(&p.started); started > 0 {
+		s.AvgWait = time.Duration(atomic.LoadInt64(&p.waitTotal) / int64(started))
+	}
+	if done := s.Completed + s.Panicked; done > 0 {
+		s.AvgRun = time.Duration(atomic.LoadInt64(&p.runTotal) / int64(done))
+	}
+	return s
+}
+
+func (p *Pool) work() {
+	defer p.wg.Done()
+	for t := range p.queue {
+		p.run(t)
+	}
+}
+
+func (p *Pool) run(t task) {
+	if t.ctx.Err() != nil {
+		atomic.AddUint64(&p.canceled, 1)
+		return
+	}
+	start := time.Now()
+	atomic.AddUint64(&p.started, 1)
+	atomic.AddInt64(&p.waitTotal, int64(start.Sub(t.queued)))
+	atomic.AddInt64(&p.running, 1)
+	defer func() {
+		atomic.AddInt64(&p.running, -1)
+		d := int64(time.Since(start))
+		atomic.AddInt64(&p.runTotal, d)
+		for {
+			max := atomic.LoadInt64(&p.maxRun)
+			if d <= max || atomic.CompareAndSwapInt64(&p.maxRun, max, d) {
+				break
+			}
+		}
+		if r := recover(); r != nil {
+			atomic.AddUint64(&p.panicked, 1)
+			log.SetFlags(log.LstdFlags)
+			log.Printf("pool %s job panic: %v\n%s\n", p.name, r, gofutils.PanicTrace(4))
+			return
+		}
+		atomic.AddUint64(&p.completed, 1)
+	}()
+	t.job(t.ctx)
+}
+
+// AllStats returns the stats of every pool, sorted by name.
+func AllStats() []Stats {
+	poolsMu.Lock()
+	stats := make([]Stats, 0, len(pools))
+	for _, p := range pools {
+		stats = append(stats, p.Stats())
+	}
+	poolsMu.Unlock()
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Name < stats[j].Name
+	})
+	return stats
+}
@@ -0,0 +1,138 @@
+/*******************************************************************************
+ * Copyright (c) 2018  charles
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NON INFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ * -------------------------------------------------------------------------
+ * created at 2026-11-08 10:03:47
+ ******************************************************************************/
+
+package gofpool
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestShutdownDrains(t *testing.T) {
+	p := New("drain", 2, 100)
+	var done int32
+	for i := 0; i < 50; i++ {
+		if err := p.Go(func() {
+			time.Sleep(time.Millisecond)
+			atomic.AddInt32(&done, 1)
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&done); n != 50 {
+		t.Fatalf("%d jobs done, want the 50 queued jobs", n)
+	}
+	if err := p.Go(func() {}); err != ErrClosed {
+		t.Fatalf("submit after shutdown: %v, want %v", err, ErrClosed)
+	}
+	s := p.Stats()
+	if s.Submitted != 50 || s.Completed != 50 || s.Queued != 0 || s.Running != 0 {
+		t.Fatalf("unexpected stats %+v", s)
+	}
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	p := New("timeout", 1, 1)
+	release := make(chan struct{})
+	p.Go(func() { <-release })
+	defer close(release)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := p.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("shutdown of a running job: %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestShutdownReleasesSubmitters(t *testing.T) {
+	p := New("blocked", 1, 0)
+	release := make(chan struct{})
+	p.Go(func() { <-release })
+	errc := make(chan error, 1)
+	go func() {
+		// no worker is free and the queue has no room
+		errc <- p.Go(func() {})
+	}()
+	time.Sleep(20 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	p.Shutdown(ctx)
+	select {
+	case err := <-errc:
+		if err != ErrClosed {
+			t.Fatalf("blocked submit: %v, want %v", err, ErrClosed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the blocked submitter was not released")
+	}
+	close(release)
+}
+
+func TestTrySubmitFull(t *testing.T) {
+	p := New("full", 1, 1)
+	defer p.Shutdown(context.Background())
+	release := make(chan struct{})
+	started := make(chan struct{})
+	p.Go(func() {
+		close(started)
+		<-release
+	})
+	<-started
+	if err := p.TrySubmit(context.Background(), func(context.Context) {}); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.TrySubmit(context.Background(), func(context.Context) {}); err != ErrQueueFull {
+		t.Fatalf("got %v, want %v", err, ErrQueueFull)
+	}
+	close(release)
+	if s := p.Stats(); s.Rejected != 1 {
+		t.Fatalf("unexpected stats %+v", s)
+	}
+}
+
+func TestPanicAndCanceled(t *testing.T) {
+	p := New("panic", 1, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	release := make(chan struct{})
+	p.Go(func() { <-release })
+	p.Go(func() { panic("job failure") })
+	p.Submit(context.Background(), func(context.Context) {})
+	// the context is done before the job starts, it is skipped
+	if err := p.TrySubmit(ctx, func(context.Context) { t.Error("a canceled job ran") }); err != nil {
+		t.Fatal(err)
+	}
+	close(release)
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	s := p.Stats()
+	if s.Panicked != 1 || s.Completed != 2 || s.Canceled != 1 {
+		t.Fatalf("unexpected stats %+v", s)
+	}
+}
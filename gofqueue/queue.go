@@ -0,0 +1,375 @@
+/*******************************************************************************
+ * Copyright (c) 2018  charles
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NON INFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ * -------------------------------------------------------------------------
+ * created at 2026-10-22 10:04:51
+ ******************************************************************************/
+
+// Package gofqueue is a persistent queue of named jobs, the payloads are stored
+// in buntdb on a single node or in redis on multiple nodes, see Store.
+// A job is run at least once: it is leased while it runs and comes back after
+// the lease when the process dies, failed jobs are retried with an exponential
+// backoff and moved to the dead-letter set after MaxAttempts.
+package gofqueue
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	mrand "math/rand"
+	"sync"
+	"time"
+
+	"github.com/atcharles/gof/gofpool"
+	"github.com/atcharles/gof/gofutils"
+	"github.com/atcharles/gof/gofutils/errors"
+)
+
+const (
+	// DefaultMaxAttempts ...
+	DefaultMaxAttempts = 10
+	// DefaultLease is the time a job may run before another worker takes it again
+	DefaultLease = 5 * time.Minute
+	// DefaultInterval is the polling interval of the store
+	DefaultInterval = time.Second
+	// DefaultWorkers ...
+	DefaultWorkers = 10
+)
+
+// ErrNotFound is returned when the job is not in the store
+var ErrNotFound = errors.New("job not found")
+
+// Job ...
+type Job struct {
+	ID          string          `json:"id"`
+	Name        string          `json:"name"`
+	Payload     json.RawMessage `json:"payload"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	RunAt       time.Time       `json:"run_at"`
+	CreatedAt   time.Time       `json:"created_at"`
+	LastError   string          `json:"last_error,omitempty"`
+	FailedAt    time.Time       `json:"failed_at,omitempty"`
+}
+
+// Bind decodes the payload into ptr.
+func (j *Job) Bind(ptr interface{}) error {
+	return json.Unmarshal(j.Payload, ptr)
+}
+
+// Handler runs a job, the job is retried when an error is returned.
+// The context is canceled when the lease expires or the queue is stopped.
+type Handler func(ctx context.Context, job *Job) error
+
+// Option of Enqueue
+type Option func(job *Job)
+
+// Delay runs the job after d.
+func Delay(d time.Duration) Option {
+	return func(job *Job) {
+		job.RunAt = time.Now().Add(d)
+	}
+}
+
+// At runs the job at t.
+func At(t time.Time) Option {
+	return func(job *Job) {
+		job.RunAt = t
+	}
+}
+
+// MaxAttempts overrides Queue.MaxAttempts for the job.
+func MaxAttempts(n int) Option {
+	return func(job *Job) {
+		job.MaxAttempts = n
+	}
+}
+
+// ID sets the id of the job, a job with the same id replaces the stored one.
+func ID(id string) Option {
+	return func(job *Job) {
+		job.ID = id
+	}
+}
+
+// Queue ...
+type Queue struct {
+	// MaxAttempts is the default number of attempts of a job
+	MaxAttempts int
+	// Lease is the time a job may run, see DefaultLease
+	Lease time.Duration
+	// Interval is the polling interval of the store
+	Interval time.Duration
+	// Backoff returns the delay before the next attempt, see Backoff
+	Backoff func(attempts int) time.Duration
+	// Workers is the number of jobs run at the same time
+	Workers int
+
+	name     string
+	store    Store
+	pool     *gofpool.Pool
+	mu       sync.RWMutex
+	handlers map[string]Handler
+	quit     chan struct{}
+	done     chan struct{}
+	ctx      context.Context
+	cancel   context.CancelFunc
+}
+
+// New creates a queue of the jobs of store, the jobs run in a gofpool named "gofqueue." + name.
+func New(name string, store Store) *Queue {
+	return &Queue{
+		MaxAttempts: DefaultMaxAttempts,
+		Lease:       DefaultLease,
+		Interval:    DefaultInterval,
+		Backoff:     Backoff,
+		Workers:     DefaultWorkers,
+		name:        name,
+		store:       store,
+		handlers:    make(map[string]Handler),
+	}
+}
+
+// Backoff doubles the delay on every attempt from one second up to an hour, with a jitter of 20%.
+func Backoff(attempts int) time.Duration {
+	d := time.Hour
+	if attempts < 13 {
+		d = time.Second << uint(attempts-1)
+	}
+	if d > time.Hour {
+		d = time.Hour
+	}
+	return d + time.Duration(mrand.Int63n(int64(d)/5+1))
+}
+
+// Register binds the handler to the jobs named name.
+func (q *Queue) Register(name string, h Handler) {
+	q.mu.Lock()
+	q.handlers[name] = h
+	q.mu.Unlock()
+}
+
+// Enqueue stores a job, the payload is encoded as json.
+func (q *Queue) Enqueue(name string, payload interface{}, opts ...Option) (*Job, error) {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	job := &Job{
+		Name:        name,
+		Payload:     b,
+		MaxAttempts: q.MaxAttempts,
+		RunAt:       now,
+		CreatedAt:   now,
+	}
+	for _, opt := range opts {
+		opt(job)
+	}
+	if job.ID == "" {
+		job.ID = newID()
+	}
+	if err := q.store.Push(job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// DeadJobs returns the jobs which ran out of attempts.
+func (q *Queue) DeadJobs() ([]*Job, error) {
+	return q.store.DeadJobs()
+}
+
+// Requeue moves a dead job back to the queue, its attempts are reset.
+func (q *Queue) Requeue(id string) error {
+	return q.store.Requeue(id, time.Now())
+}
+
+// RequeueAll moves every dead job back to the queue and returns the number of jobs.
+func (q *Queue) RequeueAll() (int, error) {
+	jobs, err := q.store.DeadJobs()
+	if err != nil {
+		return 0, err
+	}
+	var errs error
+	n := 0
+	for _, job := range jobs {
+		if err := q.Requeue(job.ID); err != nil {
+			errs = errors.Append(errs, err)
+			continue
+		}
+		n++
+	}
+	return n, errs
+}
+
+// Start polls the store until Stop.
+func (q *Queue) Start() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.quit != nil {
+		return nil
+	}
+	q.quit = make(chan struct{})
+	q.done = make(chan struct{})
+	q.ctx, q.cancel = context.WithCancel(context.Background())
+	q.pool = gofpool.New("gofqueue."+q.name, q.Workers, q.Workers)
+	go q.poll(q.pool, q.quit, q.done)
+	return nil
+}
+
+// Stop stops polling, waits for the queued and running jobs until ctx is done and closes the store.
+// The jobs which are not finished come back after their lease.
+func (q *Queue) Stop(ctx context.Context) error {
+	q.mu.Lock()
+	quit, done, cancel, pool := q.quit, q.done, q.cancel, q.pool
+	q.quit = nil
+	q.mu.Unlock()
+	if quit == nil {
+		return q.store.Close()
+	}
+	close(quit)
+	// the shutdown releases the poller when it waits for room in the pool
+	err := pool.Shutdown(ctx)
+	<-done
+	cancel()
+	return errors.Append(err, q.store.Close())
+}
+
+func (q *Queue) poll(pool *gofpool.Pool, quit, done chan struct{}) {
+	defer close(done)
+	tk := time.NewTicker(q.Interval)
+	defer tk.Stop()
+	for {
+		q.fetch(pool, quit)
+		select {
+		case <-quit:
+			return
+		case <-tk.C:
+		}
+	}
+}
+
+// fetch takes as many ready jobs as the pool has room for.
+func (q *Queue) fetch(pool *gofpool.Pool, quit chan struct{}) {
+	for {
+		s := pool.Stats()
+		room := s.QueueSize - s.Queued
+		if room <= 0 {
+			room = 1
+		}
+		jobs, err := q.store.Pop(time.Now(), room, q.Lease)
+		if err != nil {
+			log.SetFlags(log.LstdFlags)
+			log.Printf("gofqueue pop err:%s\n", err.Error())
+			return
+		}
+		for i, job := range jobs {
+			job := job
+			ctx, cancel := context.WithTimeout(q.ctx, q.Lease)
+			err := pool.Submit(ctx, func(ctx context.Context) {
+				defer cancel()
+				q.run(ctx, job)
+			})
+			if err != nil {
+				cancel()
+				q.release(jobs[i:])
+				return
+			}
+		}
+		if len(jobs) < room {
+			return
+		}
+		select {
+		case <-quit:
+			return
+		default:
+		}
+	}
+}
+
+// release gives the leased jobs back without waiting for the lease, they have not been attempted.
+func (q *Queue) release(jobs []*Job) {
+	for _, job := range jobs {
+		job.Attempts--
+		if err := q.store.Retry(job); err != nil {
+			log.SetFlags(log.LstdFlags)
+			log.Printf("gofqueue release %s err:%s\n", job.ID, err.Error())
+		}
+	}
+}
+
+// run calls the handler of the job, the attempt was counted by Pop.
+// A job whose leases expired more than MaxAttempts times, e.g. it crashes the process, is not called again.
+func (q *Queue) run(ctx context.Context, job *Job) {
+	var err error
+	if job.Attempts > job.MaxAttempts {
+		err = fmt.Errorf("job %s leased %d times without finishing", job.Name, job.Attempts-1)
+	} else {
+		err = q.call(ctx, job)
+	}
+	if err == nil {
+		if err := q.store.Ack(job); err != nil {
+			log.SetFlags(log.LstdFlags)
+			log.Printf("gofqueue ack %s err:%s\n", job.ID, err.Error())
+		}
+		return
+	}
+	job.LastError = err.Error()
+	if job.Attempts >= job.MaxAttempts {
+		job.FailedAt = time.Now()
+		err = q.store.Dead(job)
+	} else {
+		job.RunAt = time.Now().Add(q.Backoff(job.Attempts))
+		err = q.store.Retry(job)
+	}
+	if err != nil {
+		log.SetFlags(log.LstdFlags)
+		log.Printf("gofqueue retry %s err:%s\n", job.ID, err.Error())
+	}
+}
+
+func (q *Queue) call(ctx context.Context, job *Job) (err error) {
+	q.mu.RLock()
+	h, ok := q.handlers[job.Name]
+	q.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("no handler registered for job %s", job.Name)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			log.SetFlags(log.LstdFlags)
+			log.Println(string(gofutils.PanicTrace(4)))
+			err = fmt.Errorf("job %s panic: %v", job.Name, p)
+		}
+	}()
+	return h(ctx, job)
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,178 @@
+/*******************************************************************************
+ * Copyright (c) 2018  charles
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NON INFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ * -------------------------------------------------------------------------
+ * created at 2026-11-08 10:47:29
+ ******************************************************************************/
+
+package gofqueue
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/tidwall/buntdb"
+)
+
+func newTestStore(t *testing.T) *BuntStore {
+	db, err := buntdb.Open(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewBuntStore(db, KeyPrefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// newTestQueue starts a queue polling every 10ms, without backoff
+func newTestQueue(t *testing.T, name string) (*Queue, *BuntStore) {
+	s := newTestStore(t)
+	q := New(name, s)
+	q.Interval = 10 * time.Millisecond
+	q.Backoff = func(int) time.Duration { return time.Millisecond }
+	q.MaxAttempts = 3
+	t.Cleanup(func() {
+		q.Stop(context.Background())
+	})
+	return q, s
+}
+
+// waitFor polls cond until it holds or fails the test after 5s
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestLease(t *testing.T) {
+	s := newTestStore(t)
+	defer s.Close()
+	now := time.Now()
+	if err := s.Push(&Job{ID: "a", Name: "job", RunAt: now, MaxAttempts: 3}); err != nil {
+		t.Fatal(err)
+	}
+	jobs, err := s.Pop(now, 10, time.Minute)
+	if err != nil || len(jobs) != 1 || jobs[0].Attempts != 1 {
+		t.Fatalf("first pop %v %v, want the job at its first attempt", jobs, err)
+	}
+	if jobs, _ := s.Pop(now.Add(30*time.Second), 10, time.Minute); len(jobs) != 0 {
+		t.Fatalf("a leased job was popped again: %v", jobs)
+	}
+	jobs, err = s.Pop(now.Add(2*time.Minute), 10, time.Minute)
+	if err != nil || len(jobs) != 1 || jobs[0].Attempts != 2 {
+		t.Fatalf("pop after the lease %v %v, want the job at its second attempt", jobs, err)
+	}
+	if err := s.Ack(jobs[0]); err != nil {
+		t.Fatal(err)
+	}
+	if jobs, _ := s.Pop(now.Add(time.Hour), 10, time.Minute); len(jobs) != 0 {
+		t.Fatalf("an acked job was popped: %v", jobs)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		min      time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{5, 16 * time.Second},
+		{13, time.Hour},
+		{100, time.Hour},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 20; i++ {
+			if d := Backoff(tt.attempts); d < tt.min || d > tt.min+tt.min/5 {
+				t.Fatalf("Backoff(%d) = %s, want between %s and %s", tt.attempts, d, tt.min, tt.min+tt.min/5)
+			}
+		}
+	}
+}
+
+func TestRetryDeadLetter(t *testing.T) {
+	q, _ := newTestQueue(t, "dead")
+	var calls, fail int32 = 0, 1
+	q.Register("flaky", func(ctx context.Context, job *Job) error {
+		atomic.AddInt32(&calls, 1)
+		if atomic.LoadInt32(&fail) == 1 {
+			return errors.New("failure")
+		}
+		return nil
+	})
+	job, err := q.Enqueue("flaky", map[string]int{"n": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.Start()
+	var dead []*Job
+	waitFor(t, "the dead-letter set", func() bool {
+		dead, _ = q.DeadJobs()
+		return len(dead) == 1
+	})
+	if n := atomic.LoadInt32(&calls); n != 3 {
+		t.Fatalf("%d attempts, want MaxAttempts", n)
+	}
+	if d := dead[0]; d.ID != job.ID || d.Attempts != 3 || d.LastError != "failure" || d.FailedAt.IsZero() {
+		t.Fatalf("unexpected dead job %+v", d)
+	}
+
+	atomic.StoreInt32(&fail, 0)
+	if n, err := q.RequeueAll(); err != nil || n != 1 {
+		t.Fatalf("requeue %d %v", n, err)
+	}
+	waitFor(t, "the requeued job", func() bool {
+		return atomic.LoadInt32(&calls) == 4
+	})
+	if dead, _ := q.DeadJobs(); len(dead) != 0 {
+		t.Fatalf("dead jobs after the requeue: %v", dead)
+	}
+}
+
+func TestLeaseExpiredTooOften(t *testing.T) {
+	q, s := newTestQueue(t, "crash")
+	var calls int32
+	q.Register("crash", func(ctx context.Context, job *Job) error {
+		atomic.AddInt32(&calls, 1)
+		return nil
+	})
+	// the job used every attempt without finishing, e.g. it crashed the process
+	if err := s.Push(&Job{ID: "c", Name: "crash", RunAt: time.Now(), Attempts: 3, MaxAttempts: 3}); err != nil {
+		t.Fatal(err)
+	}
+	q.Start()
+	waitFor(t, "the dead-letter set", func() bool {
+		dead, _ := q.DeadJobs()
+		return len(dead) == 1
+	})
+	if n := atomic.LoadInt32(&calls); n != 0 {
+		t.Fatalf("the handler was called %d times", n)
+	}
+}
@@ -0,0 +1,456 @@
+/*******************************************************************************
+ * Copyright (c) 2018  charles
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NON INFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ * -------------------------------------------------------------------------
+ * created at 2026-10-22 10:31:08
+ ******************************************************************************/
+
+package gofqueue
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/atcharles/gof/gofcache"
+	"github.com/atcharles/gof/gofconf"
+	"github.com/atcharles/gof/gofutils"
+	"github.com/go-redis/redis"
+	"github.com/tidwall/buntdb"
+)
+
+// KeyPrefix is the prefix of the keys written by the stores of NewStore.
+const KeyPrefix = "gofqueue:"
+
+// Store keeps the jobs, a job is either scheduled (ready at RunAt, or leased) or dead.
+type Store interface {
+	// Push schedules the job at job.RunAt, replacing the job with the same id
+	Push(job *Job) error
+	// Pop returns at most n jobs ready at now and leases them for lease,
+	// the attempt is counted in the store with the lease
+	Pop(now time.Time, n int, lease time.Duration) ([]*Job, error)
+	// Ack removes a finished job
+	Ack(job *Job) error
+	// Retry saves the job and schedules it again at job.RunAt
+	Retry(job *Job) error
+	// Dead moves the job to the dead-letter set
+	Dead(job *Job) error
+	// DeadJobs returns the dead-letter set
+	DeadJobs() ([]*Job, error)
+	// Requeue moves a dead job back to the schedule at now, with its attempts reset
+	Requeue(id string, now time.Time) error
+	Close() error
+}
+
+// NewStore returns a redis store when gofconf.DefaultProcess.CacheType is redis,
+// and a buntdb store in SelfDir()/data/gofqueue.db otherwise.
+func NewStore() (Store, error) {
+	if gofconf.DefaultProcess.CacheType == "redis" {
+		return NewRedisStore(gofcache.NewRedisCache().Client, KeyPrefix), nil
+	}
+	return OpenBuntStore(gofutils.SelfDir()+"data/gofqueue.db", KeyPrefix)
+}
+
+// RedisStore keeps the jobs and their attempts in hashes, the schedule and the dead-letter set in sorted sets.
+type RedisStore struct {
+	client   *redis.Client
+	jobs     string
+	attempts string
+	sched    string
+	dead     string
+}
+
+// popScript leases the ready jobs and counts their attempts atomically, the ids without a job are dropped.
+// It returns the job and its attempts for every job, a job saved without attempts starts from its own.
+var popScript = redis.NewScript(`
+local ids = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[2])
+local out = {}
+for _, id in ipairs(ids) do
+	local v = redis.call('HGET', KEYS[2], id)
+	if v then
+		if redis.call('HEXISTS', KEYS[3], id) == 0 then
+			redis.call('HSET', KEYS[3], id, tonumber(cjson.decode(v)['attempts']) or 0)
+		end
+		redis.call('ZADD', KEYS[1], ARGV[3], id)
+		table.insert(out, v)
+		table.insert(out, redis.call('HINCRBY', KEYS[3], id, 1))
+	else
+		redis.call('ZREM', KEYS[1], id)
+		redis.call('HDEL', KEYS[3], id)
+	end
+end
+return out
+`)
+
+// NewRedisStore uses client, the client is not closed by Close.
+func NewRedisStore(client *redis.Client, prefix string) *RedisStore {
+	return &RedisStore{
+		client:   client,
+		jobs:     prefix + "jobs",
+		attempts: prefix + "attempts",
+		sched:    prefix + "schedule",
+		dead:     prefix + "dead",
+	}
+}
+
+func score(t time.Time) float64 {
+	return float64(t.UnixNano() / int64(time.Millisecond))
+}
+
+// Push ...
+func (r *RedisStore) Push(job *Job) error {
+	b, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	_, err = r.client.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.HSet(r.jobs, job.ID, b)
+		pipe.HSet(r.attempts, job.ID, job.Attempts)
+		pipe.ZRem(r.dead, job.ID)
+		pipe.ZAdd(r.sched, redis.Z{Score: score(job.RunAt), Member: job.ID})
+		return nil
+	})
+	return err
+}
+
+// Pop ...
+func (r *RedisStore) Pop(now time.Time, n int, lease time.Duration) ([]*Job, error) {
+	vals, err := popScript.Run(r.client, []string{r.sched, r.jobs, r.attempts},
+		strconv.FormatFloat(score(now), 'f', 0, 64), n,
+		strconv.FormatFloat(score(now.Add(lease)), 'f', 0, 64)).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, err
+	}
+	list, _ := vals.([]interface{})
+	jobs := make([]*Job, 0, len(list)/2)
+	for i := 0; i+1 < len(list); i += 2 {
+		s, _ := list[i].(string)
+		job := new(Job)
+		if err := json.Unmarshal([]byte(s), job); err != nil {
+			return jobs, err
+		}
+		attempts, _ := list[i+1].(int64)
+		job.Attempts = int(attempts)
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+// Ack ...
+func (r *RedisStore) Ack(job *Job) error {
+	_, err := r.client.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.ZRem(r.sched, job.ID)
+		pipe.HDel(r.jobs, job.ID)
+		pipe.HDel(r.attempts, job.ID)
+		return nil
+	})
+	return err
+}
+
+// Retry ...
+func (r *RedisStore) Retry(job *Job) error {
+	return r.Push(job)
+}
+
+// Dead ...
+func (r *RedisStore) Dead(job *Job) error {
+	b, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	_, err = r.client.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.HSet(r.jobs, job.ID, b)
+		pipe.HSet(r.attempts, job.ID, job.Attempts)
+		pipe.ZRem(r.sched, job.ID)
+		pipe.ZAdd(r.dead, redis.Z{Score: score(job.FailedAt), Member: job.ID})
+		return nil
+	})
+	return err
+}
+
+// DeadJobs ...
+func (r *RedisStore) DeadJobs() ([]*Job, error) {
+	ids, err := r.client.ZRange(r.dead, 0, -1).Result()
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+	vals, err := r.client.HMGet(r.jobs, ids...).Result()
+	if err != nil {
+		return nil, err
+	}
+	jobs := make([]*Job, 0, len(vals))
+	for _, v := range vals {
+		s, ok := v.(string)
+		if !ok {
+			continue
+		}
+		job := new(Job)
+		if err := json.Unmarshal([]byte(s), job); err != nil {
+			return jobs, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+// Requeue ...
+func (r *RedisStore) Requeue(id string, now time.Time) error {
+	if err := r.client.ZScore(r.dead, id).Err(); err != nil {
+		if err == redis.Nil {
+			return ErrNotFound
+		}
+		return err
+	}
+	v, err := r.client.HGet(r.jobs, id).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return ErrNotFound
+		}
+		return err
+	}
+	job := new(Job)
+	if err := json.Unmarshal(v, job); err != nil {
+		return err
+	}
+	resetJob(job, now)
+	return r.Push(job)
+}
+
+// Close ...
+func (r *RedisStore) Close() error {
+	return nil
+}
+
+// BuntStore keeps the jobs in a buntdb file, the schedule is an index on the schedule keys.
+type BuntStore struct {
+	db     *buntdb.DB
+	prefix string
+	index  string
+}
+
+// OpenBuntStore opens the buntdb file at path, every write is synced to the disk.
+func OpenBuntStore(path, prefix string) (*BuntStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	db, err := buntdb.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg buntdb.Config
+	if err := db.ReadConfig(&cfg); err != nil {
+		db.Close()
+		return nil, err
+	}
+	cfg.SyncPolicy = buntdb.Always
+	if err := db.SetConfig(cfg); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return NewBuntStore(db, prefix)
+}
+
+// NewBuntStore uses db, the db is closed by Close.
+func NewBuntStore(db *buntdb.DB, prefix string) (*BuntStore, error) {
+	b := &BuntStore{db: db, prefix: prefix, index: prefix + "schedule"}
+	err := db.CreateIndex(b.index, prefix+"schedule:*", buntdb.IndexString)
+	if err != nil && err != buntdb.ErrIndexExists {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (b *BuntStore) jobKey(id string) string   { return b.prefix + "job:" + id }
+func (b *BuntStore) schedKey(id string) string { return b.prefix + "schedule:" + id }
+func (b *BuntStore) deadKey(id string) string  { return b.prefix + "dead:" + id }
+
+// stamp is sortable as a string
+func stamp(t time.Time) string {
+	return fmt.Sprintf("%020d", t.UnixNano())
+}
+
+func (b *BuntStore) save(tx *buntdb.Tx, job *Job) error {
+	v, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	_, _, err = tx.Set(b.jobKey(job.ID), string(v), nil)
+	return err
+}
+
+func (b *BuntStore) load(tx *buntdb.Tx, id string) (*Job, error) {
+	v, err := tx.Get(b.jobKey(id))
+	if err != nil {
+		if err == buntdb.ErrNotFound {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	job := new(Job)
+	return job, json.Unmarshal([]byte(v), job)
+}
+
+func (b *BuntStore) schedule(tx *buntdb.Tx, job *Job) error {
+	if err := b.save(tx, job); err != nil {
+		return err
+	}
+	if _, err := tx.Delete(b.deadKey(job.ID)); err != nil && err != buntdb.ErrNotFound {
+		return err
+	}
+	_, _, err := tx.Set(b.schedKey(job.ID), stamp(job.RunAt), nil)
+	return err
+}
+
+// Push ...
+func (b *BuntStore) Push(job *Job) error {
+	return b.db.Update(func(tx *buntdb.Tx) error {
+		return b.schedule(tx, job)
+	})
+}
+
+// Pop ...
+func (b *BuntStore) Pop(now time.Time, n int, lease time.Duration) ([]*Job, error) {
+	var jobs []*Job
+	err := b.db.Update(func(tx *buntdb.Tx) error {
+		var keys []string
+		err := tx.AscendLessThan(b.index, stamp(now.Add(time.Nanosecond)), func(key, _ string) bool {
+			keys = append(keys, key)
+			return len(keys) < n
+		})
+		if err != nil {
+			return err
+		}
+		until := stamp(now.Add(lease))
+		for _, key := range keys {
+			id := key[len(b.prefix+"schedule:"):]
+			job, err := b.load(tx, id)
+			if err == ErrNotFound {
+				tx.Delete(key)
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			job.Attempts++
+			if err := b.save(tx, job); err != nil {
+				return err
+			}
+			if _, _, err := tx.Set(key, until, nil); err != nil {
+				return err
+			}
+			jobs = append(jobs, job)
+		}
+		return nil
+	})
+	return jobs, err
+}
+
+// Ack ...
+func (b *BuntStore) Ack(job *Job) error {
+	return b.db.Update(func(tx *buntdb.Tx) error {
+		for _, key := range []string{b.schedKey(job.ID), b.jobKey(job.ID)} {
+			if _, err := tx.Delete(key); err != nil && err != buntdb.ErrNotFound {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Retry ...
+func (b *BuntStore) Retry(job *Job) error {
+	return b.Push(job)
+}
+
+// Dead ...
+func (b *BuntStore) Dead(job *Job) error {
+	return b.db.Update(func(tx *buntdb.Tx) error {
+		if err := b.save(tx, job); err != nil {
+			return err
+		}
+		if _, err := tx.Delete(b.schedKey(job.ID)); err != nil && err != buntdb.ErrNotFound {
+			return err
+		}
+		_, _, err := tx.Set(b.deadKey(job.ID), stamp(job.FailedAt), nil)
+		return err
+	})
+}
+
+// DeadJobs ...
+func (b *BuntStore) DeadJobs() ([]*Job, error) {
+	var jobs []*Job
+	err := b.db.View(func(tx *buntdb.Tx) error {
+		var ids []string
+		err := tx.AscendKeys(b.prefix+"dead:*", func(key, _ string) bool {
+			ids = append(ids, key[len(b.prefix+"dead:"):])
+			return true
+		})
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			job, err := b.load(tx, id)
+			if err == ErrNotFound {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			jobs = append(jobs, job)
+		}
+		return nil
+	})
+	return jobs, err
+}
+
+// Requeue ...
+func (b *BuntStore) Requeue(id string, now time.Time) error {
+	return b.db.Update(func(tx *buntdb.Tx) error {
+		if _, err := tx.Get(b.deadKey(id)); err != nil {
+			if err == buntdb.ErrNotFound {
+				return ErrNotFound
+			}
+			return err
+		}
+		job, err := b.load(tx, id)
+		if err != nil {
+			return err
+		}
+		resetJob(job, now)
+		return b.schedule(tx, job)
+	})
+}
+
+// Close ...
+func (b *BuntStore) Close() error {
+	return b.db.Close()
+}
+
+func resetJob(job *Job, now time.Time) {
+	job.Attempts = 0
+	job.RunAt = now
+	job.FailedAt = time.Time{}
+}
@@ -0,0 +1,243 @@
+/*******************************************************************************
+ * Copyright (c) 2018  charles
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NON INFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ * -------------------------------------------------------------------------
+ * created at 2026-10-26 16:22:05
+ ******************************************************************************/
+
+// Package gofrender renders the responses in the encoding picked from the Accept header
+// and binds the request bodies by their Content-Type, the encodings are registered by MIME type.
+package gofrender
+
+import (
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/atcharles/gof/gofconf"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/gin-gonic/gin/render"
+	"github.com/golang/protobuf/proto"
+)
+
+// Additional MIME types of the default renderers and binders
+const (
+	MIMEApplicationXMsgpack  = "application/x-msgpack"
+	MIMEApplicationXProtobuf = "application/x-protobuf"
+)
+
+type (
+	// Renderer encodes the responses of a MIME type.
+	Renderer struct {
+		Render func(data interface{}) render.Render
+		// Accepts reports whether data can be encoded, nil accepts any data
+		Accepts func(data interface{}) bool
+	}
+
+	// protoBuf renders a protobuf message, the pinned gin has no render.ProtoBuf
+	protoBuf struct {
+		Data proto.Message
+	}
+)
+
+var (
+	// Default is the MIME type rendered when the Accept header matches no renderer,
+	// an empty Default makes Negotiate return gofconf.ErrNotAcceptable.
+	Default = gofconf.MIMEApplicationJSON
+
+	mu        sync.RWMutex
+	order     []string
+	renderers = make(map[string]Renderer)
+	binders   = make(map[string]binding.Binding)
+)
+
+// WriteContentType ...
+func (r protoBuf) WriteContentType(w http.ResponseWriter) {
+	if header := w.Header(); header.Get("Content-Type") == "" {
+		header.Set("Content-Type", MIMEApplicationXProtobuf)
+	}
+}
+
+// Render ...
+func (r protoBuf) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	b, err := proto.Marshal(r.Data)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+func init() {
+	jsonR := Renderer{Render: func(data interface{}) render.Render { return render.JSON{Data: data} }}
+	xmlR := Renderer{Render: func(data interface{}) render.Render { return render.XML{Data: data} }}
+	msgpackR := Renderer{Render: func(data interface{}) render.Render { return render.MsgPack{Data: data} }}
+	protoR := Renderer{
+		Render: func(data interface{}) render.Render {
+			msg, _ := data.(proto.Message)
+			return protoBuf{Data: msg}
+		},
+		Accepts: func(data interface{}) bool {
+			_, ok := data.(proto.Message)
+			return ok
+		},
+	}
+	// the order breaks the ties of the Accept header
+	Register(gofconf.MIMEApplicationJSON, jsonR)
+	Register(gofconf.MIMEApplicationXML, xmlR)
+	Register(gofconf.MIMETextXML, xmlR)
+	Register(gofconf.MIMEApplicationMsgpack, msgpackR)
+	Register(MIMEApplicationXMsgpack, msgpackR)
+	Register(gofconf.MIMEApplicationProtobuf, protoR)
+	Register(MIMEApplicationXProtobuf, protoR)
+
+	RegisterBinder(gofconf.MIMEApplicationJSON, binding.JSON)
+	RegisterBinder(gofconf.MIMEApplicationXML, binding.XML)
+	RegisterBinder(gofconf.MIMETextXML, binding.XML)
+	RegisterBinder(gofconf.MIMEApplicationMsgpack, binding.MsgPack)
+	RegisterBinder(MIMEApplicationXMsgpack, binding.MsgPack)
+	RegisterBinder(gofconf.MIMEApplicationProtobuf, binding.ProtoBuf)
+	RegisterBinder(MIMEApplicationXProtobuf, binding.ProtoBuf)
+	RegisterBinder(gofconf.MIMEApplicationForm, binding.Form)
+	RegisterBinder(gofconf.MIMEMultipartForm, binding.FormMultipart)
+}
+
+// Register sets the renderer of a MIME type, replacing the registered one.
+func Register(mimeType string, r Renderer) {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := renderers[mimeType]; !ok {
+		order = append(order, mimeType)
+	}
+	renderers[mimeType] = r
+}
+
+// RegisterBinder sets the binder of a MIME type, replacing the registered one.
+func RegisterBinder(mimeType string, b binding.Binding) {
+	mu.Lock()
+	defer mu.Unlock()
+	binders[mimeType] = b
+}
+
+// Negotiate renders data with the renderer preferred by the Accept header, see Default.
+// A missing Accept header accepts any type.
+func Negotiate(c *gin.Context, code int, data interface{}) error {
+	mimeType, ok := match(c.GetHeader(gofconf.HeaderAccept), data)
+	if !ok {
+		if Default == "" {
+			return gofconf.ErrNotAcceptable
+		}
+		mimeType = Default
+	}
+	return Render(c, code, mimeType, data)
+}
+
+// Render renders data with the renderer of a MIME type.
+func Render(c *gin.Context, code int, mimeType string, data interface{}) error {
+	mu.RLock()
+	r, ok := renderers[mimeType]
+	mu.RUnlock()
+	if !ok {
+		return gofconf.ErrRendererNotRegistered
+	}
+	if r.Accepts != nil && !r.Accepts(data) {
+		return gofconf.ErrNotAcceptable
+	}
+	c.Header(gofconf.HeaderVary, gofconf.HeaderAccept)
+	c.Render(code, r.Render(data))
+	return nil
+}
+
+// Bind decodes the body with the binder of the Content-Type, and validates it like gin.
+// The errors are a gofconf.ErrUnsupportedMediaType or a 400 gofconf.HTTPError.
+func Bind(c *gin.Context, ptr interface{}) error {
+	mimeType, _, err := mime.ParseMediaType(c.GetHeader(gofconf.HeaderContentType))
+	if err != nil {
+		return gofconf.ErrUnsupportedMediaType
+	}
+	mu.RLock()
+	b, ok := binders[mimeType]
+	mu.RUnlock()
+	if !ok {
+		return gofconf.ErrUnsupportedMediaType
+	}
+	if err := c.ShouldBindWith(ptr, b); err != nil {
+		return gofconf.NewHTTPError(http.StatusBadRequest).WithDetails(err.Error())
+	}
+	return nil
+}
+
+type accepted struct {
+	mimeType string
+	q        float64
+}
+
+// match returns the registered type preferred by the header which can encode data.
+func match(header string, data interface{}) (string, bool) {
+	if strings.TrimSpace(header) == "" {
+		header = "*/*"
+	}
+	var list []accepted
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		a := accepted{mimeType: strings.ToLower(strings.TrimSpace(fields[0])), q: 1}
+		for _, f := range fields[1:] {
+			f = strings.TrimSpace(f)
+			if strings.HasPrefix(f, "q=") {
+				if v, err := strconv.ParseFloat(f[2:], 64); err == nil {
+					a.q = v
+				}
+			}
+		}
+		if a.mimeType != "" && a.q > 0 {
+			list = append(list, a)
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].q > list[j].q
+	})
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, a := range list {
+		for _, m := range order {
+			r := renderers[m]
+			if matchType(a.mimeType, m) && (r.Accepts == nil || r.Accepts(data)) {
+				return m, true
+			}
+		}
+	}
+	return "", false
+}
+
+// matchType matches the type with the wildcards of the Accept header, */* and type/*.
+func matchType(pattern, mimeType string) bool {
+	if pattern == "*/*" || pattern == mimeType {
+		return true
+	}
+	if strings.HasSuffix(pattern, "/*") {
+		return strings.HasPrefix(mimeType, strings.TrimSuffix(pattern, "*"))
+	}
+	return false
+}
@@ -0,0 +1,150 @@
+/*******************************************************************************
+ * Copyright (c) 2018  charles
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NON INFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ * -------------------------------------------------------------------------
+ * created at 2026-11-08 15:02:19
+ ******************************************************************************/
+
+package gofrender
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/atcharles/gof/gofconf"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/duration"
+)
+
+type user struct {
+	Name string `json:"name" xml:"name" binding:"required"`
+}
+
+func TestMatch(t *testing.T) {
+	msg := &duration.Duration{Seconds: 3}
+	tests := []struct {
+		name   string
+		accept string
+		data   interface{}
+		want   string
+	}{
+		{"no header", "", user{}, gofconf.MIMEApplicationJSON},
+		{"any", "*/*", user{}, gofconf.MIMEApplicationJSON},
+		{"xml", "application/xml", user{}, gofconf.MIMEApplicationXML},
+		{"case", "Application/XML", user{}, gofconf.MIMEApplicationXML},
+		{"quality", "application/json;q=0.5, text/xml", user{}, gofconf.MIMETextXML},
+		{"wildcard subtype", "text/*", user{}, gofconf.MIMETextXML},
+		{"msgpack", "application/x-msgpack", user{}, MIMEApplicationXMsgpack},
+		{"protobuf", "application/x-protobuf, application/json;q=0.1", msg, MIMEApplicationXProtobuf},
+		{"not a message", "application/x-protobuf, application/json;q=0.1", user{}, gofconf.MIMEApplicationJSON},
+		{"refused", "application/json;q=0, text/html", user{}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := match(tt.accept, tt.data)
+			if got != tt.want || ok != (tt.want != "") {
+				t.Fatalf("match(%q) = %q %v, want %q", tt.accept, got, ok, tt.want)
+			}
+		})
+	}
+}
+
+// negotiate serves data with Negotiate and returns the response to a request accepting accept
+func negotiate(accept string, data interface{}) (*httptest.ResponseRecorder, error) {
+	gin.SetMode(gin.TestMode)
+	var err error
+	r := gin.New()
+	r.GET("/", func(c *gin.Context) {
+		err = Negotiate(c, http.StatusOK, data)
+	})
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(gofconf.HeaderAccept, accept)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w, err
+}
+
+func TestNegotiate(t *testing.T) {
+	w, err := negotiate("text/xml", user{Name: "ann"})
+	if err != nil || !strings.Contains(w.Body.String(), "<name>ann</name>") ||
+		!strings.HasPrefix(w.Header().Get("Content-Type"), "application/xml") || w.Header().Get(gofconf.HeaderVary) != gofconf.HeaderAccept {
+		t.Fatalf("xml response %v %v %s", err, w.Header(), w.Body.String())
+	}
+
+	msg := &duration.Duration{Seconds: 3}
+	want, _ := proto.Marshal(msg)
+	w, err = negotiate(MIMEApplicationXProtobuf, msg)
+	if err != nil || !bytes.Equal(w.Body.Bytes(), want) || w.Header().Get("Content-Type") != MIMEApplicationXProtobuf {
+		t.Fatalf("protobuf response %v %v %q", err, w.Header(), w.Body.Bytes())
+	}
+
+	// the Default type is rendered when nothing matches, or nothing at all without a Default
+	w, err = negotiate("text/html", user{Name: "ann"})
+	if err != nil || strings.TrimSpace(w.Body.String()) != `{"name":"ann"}` {
+		t.Fatalf("default response %v %s", err, w.Body.String())
+	}
+	Default = ""
+	defer func() {
+		Default = gofconf.MIMEApplicationJSON
+	}()
+	if _, err := negotiate("text/html", user{}); err != gofconf.ErrNotAcceptable {
+		t.Fatalf("got %v, want %v", err, gofconf.ErrNotAcceptable)
+	}
+}
+
+func TestBind(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		status      int
+	}{
+		{"json", "application/json; charset=utf-8", `{"name":"ann"}`, 0},
+		{"xml", "text/xml", `<user><name>ann</name></user>`, 0},
+		{"invalid", "application/json", `{"name":`, http.StatusBadRequest},
+		{"validation", "application/json", `{}`, http.StatusBadRequest},
+		{"unsupported", "text/csv", `name\nann`, http.StatusUnsupportedMediaType},
+		{"no content type", "", `{"name":"ann"}`, http.StatusUnsupportedMediaType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			c.Request.Header.Set(gofconf.HeaderContentType, tt.contentType)
+			var u user
+			err := Bind(c, &u)
+			if tt.status == 0 {
+				if err != nil || u.Name != "ann" {
+					t.Fatalf("bind %+v %v", u, err)
+				}
+				return
+			}
+			he, ok := err.(*gofconf.HTTPError)
+			if !ok || he.Code != tt.status {
+				t.Fatalf("got %v, want a %d error", err, tt.status)
+			}
+		})
+	}
+}
@@ -25,9 +25,11 @@
 package gofxorm
 
 import (
+	"context"
 	"fmt"
 	"time"
 
+	"github.com/atcharles/gof/gofconf"
 	"github.com/atcharles/gof/goflogger"
 	"github.com/atcharles/gof/gofutils"
 	"github.com/atcharles/gof/gofutils/errors"
@@ -42,7 +44,7 @@ import (
 
 var (
 	Engine      *xorm.EngineGroup
-	configName  = "xorm_database.yaml"
+	configName  = "xorm_database"
 	vp          = viper.New()
 	defaultConf = Conf{
 		UserCache: true,
@@ -73,37 +75,66 @@ type (
 		Address  string
 		Port     int
 		User     string `yaml:",omitempty"`
-		Password string `yaml:",omitempty"`
+		Password string `yaml:",omitempty" secret:"true"`
 		DB       string `yaml:",omitempty"`
 	}
 )
 
+//Initialize connects the databases, it exits the program on error, see Start
 func Initialize() {
-	if err := settingDatabase(); err != nil {
+	if err := Start(); err != nil {
 		log.Fatalln(err.Error())
 	}
 }
 
+//Start connects the databases
+func Start() error {
+	return settingDatabase()
+}
+
+//Stop closes the connections, the statements already running are completed first
+func Stop(ctx context.Context) error {
+	if Engine == nil {
+		return nil
+	}
+	return Engine.Close()
+}
+
 //readConf ... Read configuration information
 func readConf() error {
-	fileName := gofutils.SelfDir() + "conf/" + configName
-	if err := gofutils.TouchFile(fileName); err != nil {
-		return err
+	// The database file lives next to the global file and uses its format
+	fileName := gofconf.ConfigPath(configName + gofconf.ConfigExt())
+	ptr := &defaultConf
+	key := gofutils.SnakeString(gofutils.ObjectName(ptr))
+	if gofconf.IsBootstrap() {
+		if err := gofconf.TouchConfigFile(fileName); err != nil {
+			return err
+		}
 	}
 	vp.SetConfigFile(fileName)
-	if err := vp.ReadInConfig(); err != nil {
-		return err
+	if gofutils.FileExists(fileName) {
+		if err := vp.ReadInConfig(); err != nil {
+			return err
+		}
 	}
-	ptr := &defaultConf
-	key := gofutils.SnakeString(gofutils.ObjectName(ptr))
 	if vp.IsSet(key) {
-		return vp.UnmarshalKey(key, ptr)
-	}
-	vp.Set(key, ptr)
-	if err := vp.WriteConfig(); err != nil {
-		return err
+		if err := vp.UnmarshalKey(key, ptr); err != nil {
+			return err
+		}
+	} else if !gofconf.IsBootstrap() {
+		gofconf.WarnDefaults(fileName, key)
+	} else {
+		value, err := gofconf.EncryptSecrets(ptr)
+		if err != nil {
+			return err
+		}
+		vp.Set(key, value)
+		if err := vp.WriteConfig(); err != nil {
+			return err
+		}
 	}
-	return nil
+	// e.g. GOF_XORM_DATABASE_MASTER_PASSWORD
+	return gofconf.ResolveObj(gofconf.EnvName(configName), ptr)
 }
 
 //createDatabaseEngine  The database connection is created
@@ -154,7 +185,7 @@ func settingDatabase() error {
 	Engine.ShowSQL(defaultConf.ShowSQL)
 	fName := gofutils.SelfDir() + "logs/sql/sql.log"
 	if defaultConf.ShowSQL {
-		Engine.SetLogger(xorm.NewSimpleLogger(goflogger.GetFile(fName).GetFile()))
+		Engine.SetLogger(xorm.NewSimpleLogger(goflogger.GetFile(fName)))
 		Engine.SetLogLevel(xormcore.LOG_INFO)
 	}else{
 		Engine.SetLogger(xorm.NewSimpleLogger(nil))